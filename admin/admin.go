@@ -0,0 +1,237 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package admin provides an http.Handler for operating a TimeWheel by JSON endpoints.
+//
+// The handler serves the following endpoints, the paths are relative to where the
+// handler is mounted, e.g. mount it with http.StripPrefix:
+//
+//	mux.Handle("/debug/timewheel/", http.StripPrefix("/debug/timewheel", admin.Handler(tw)))
+//
+//	GET    /                     the status of TimeWheel.
+//	GET    /timers[?tag=t]       list the pending timers, optionally filtered by tag.
+//	POST   /timers/{id}/trigger  run the job of timer immediately, and wait for its result.
+//	DELETE /timers/{id}          cancel the timer by id.
+//	DELETE /keys/{key}           cancel the timer by key.
+//	POST   /pause                pause the TimeWheel.
+//	POST   /resume               resume the TimeWheel.
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yu31/timewheel-go"
+)
+
+// Status is the response of "GET /".
+type Status struct {
+	Paused  bool `json:"paused"`
+	Pending int  `json:"pending"`
+	Held    int  `json:"held"`
+}
+
+// Timer is the JSON form of a pending timer.
+type Timer struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Key       string    `json:"key,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	JobType   string    `json:"job_type,omitempty"`
+	Spec      string    `json:"spec,omitempty"`
+	Recurring bool      `json:"recurring"`
+	NextFire  time.Time `json:"next_fire"`
+	Level     int       `json:"level"`
+	Held      bool      `json:"held,omitempty"`
+	Deferred  bool      `json:"deferred,omitempty"`
+	History   []*Run    `json:"history,omitempty"`
+}
+
+// Run is the JSON form of a run in the history of timer.
+type Run struct {
+	Scheduled time.Time `json:"scheduled"`
+	Start     time.Time `json:"start"`
+	Duration  string    `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+	Panicked  bool      `json:"panicked,omitempty"`
+	Triggered bool      `json:"triggered,omitempty"`
+}
+
+// TriggerResult is the response of "POST /timers/{id}/trigger".
+type TriggerResult struct {
+	Error string `json:"error,omitempty"`
+}
+
+type handler struct {
+	tw *timewheel.TimeWheel
+}
+
+// Handler returns an http.Handler that serves the admin endpoints of tw.
+func Handler(tw *timewheel.TimeWheel) http.Handler {
+	return &handler{tw: tw}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "":
+		h.only(w, r, http.MethodGet, h.status)
+	case len(parts) == 1 && parts[0] == "timers":
+		h.only(w, r, http.MethodGet, h.list)
+	case len(parts) == 2 && parts[0] == "timers":
+		h.only(w, r, http.MethodDelete, func(w http.ResponseWriter, r *http.Request) { h.cancel(w, parts[1]) })
+	case len(parts) == 3 && parts[0] == "timers" && parts[2] == "trigger":
+		h.only(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) { h.trigger(w, r, parts[1]) })
+	case len(parts) == 2 && parts[0] == "keys":
+		h.only(w, r, http.MethodDelete, func(w http.ResponseWriter, r *http.Request) { h.cancelKey(w, parts[1]) })
+	case len(parts) == 1 && parts[0] == "pause":
+		h.only(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			h.tw.Pause()
+			h.status(w, r)
+		})
+	case len(parts) == 1 && parts[0] == "resume":
+		h.only(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			h.tw.Resume()
+			h.status(w, r)
+		})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// only calls fn if the request method is method.
+func (h *handler) only(w http.ResponseWriter, r *http.Request, method string, fn http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	fn(w, r)
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &Status{
+		Paused:  h.tw.Paused(),
+		Pending: h.tw.Len(),
+		Held:    h.tw.Held(),
+	})
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	tag := r.URL.Query().Get("tag")
+
+	timers := make([]*Timer, 0)
+	h.tw.Range(func(info timewheel.TimerInfo) bool {
+		if tag != "" && !contains(info.Tags, tag) {
+			return true
+		}
+		timers = append(timers, &Timer{
+			ID:        info.ID,
+			Name:      info.Name,
+			Key:       info.Key,
+			Tags:      info.Tags,
+			JobType:   info.JobType,
+			Spec:      info.Spec,
+			Recurring: info.Recurring,
+			NextFire:  info.Expiration,
+			Level:     info.Level,
+			Held:      info.Held,
+			Deferred:  info.Deferred,
+			History:   runs(info.History),
+		})
+		return true
+	})
+	sort.Slice(timers, func(i, j int) bool { return timers[i].NextFire.Before(timers[j].NextFire) })
+
+	writeJSON(w, http.StatusOK, timers)
+}
+
+func (h *handler) lookup(w http.ResponseWriter, s string) (*timewheel.Timer, bool) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid timer id")
+		return nil, false
+	}
+	t, ok := h.tw.Lookup(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "timer not found")
+		return nil, false
+	}
+	return t, true
+}
+
+func (h *handler) trigger(w http.ResponseWriter, r *http.Request, s string) {
+	t, ok := h.lookup(w, s)
+	if !ok {
+		return
+	}
+	result := &TriggerResult{}
+	if err := t.Trigger(r.Context()); err != nil {
+		result.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *handler) cancel(w http.ResponseWriter, s string) {
+	t, ok := h.lookup(w, s)
+	if !ok {
+		return
+	}
+	t.Close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) cancelKey(w http.ResponseWriter, key string) {
+	if !h.tw.Cancel(key) {
+		writeError(w, http.StatusNotFound, "timer not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func runs(history []timewheel.Run) []*Run {
+	if len(history) == 0 {
+		return nil
+	}
+	runs := make([]*Run, 0, len(history))
+	for i := range history {
+		run := &Run{
+			Scheduled: history[i].Scheduled,
+			Start:     history[i].Start,
+			Duration:  history[i].Duration.String(),
+			Panicked:  history[i].Panicked,
+			Triggered: history[i].Triggered,
+		}
+		if history[i].Err != nil {
+			run.Error = history[i].Err.Error()
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func contains(tags []string, tag string) bool {
+	for _, v := range tags {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yu31/timewheel-go"
+)
+
+func do(t *testing.T, h http.Handler, method, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if v != nil {
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	tw := timewheel.Default(timewheel.WithHistory(4))
+	tw.Start()
+	defer tw.Stop()
+
+	fn := timewheel.JobFunc(func(ctx context.Context) error { return errors.New("failed") })
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), fn, timewheel.WithTags("a"))
+	t2 := tw.ScheduleJob(context.Background(), timewheel.Every(time.Minute), fn)
+	tw.Upsert(context.Background(), "k", time.Now().Add(time.Hour*2), fn)
+
+	h := Handler(tw)
+
+	var status Status
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/", &status))
+	require.Equal(t, Status{Paused: false, Pending: 3, Held: 0}, status)
+
+	var timers []*Timer
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/timers", &timers))
+	require.Equal(t, 3, len(timers))
+	require.Equal(t, t2.ID(), timers[0].ID)
+	require.Equal(t, "@every 1m0s", timers[0].Spec)
+	require.Equal(t, t1.ID(), timers[1].ID)
+	require.Equal(t, "k", timers[2].Key)
+
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/timers?tag=a", &timers))
+	require.Equal(t, 1, len(timers))
+	require.Equal(t, []string{"a"}, timers[0].Tags)
+
+	var result TriggerResult
+	require.Equal(t, http.StatusOK, do(t, h, "POST", fmt.Sprintf("/timers/%d/trigger", t2.ID()), &result))
+	require.Equal(t, "failed", result.Error)
+
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/timers?tag=", &timers))
+	require.Equal(t, t2.ID(), timers[0].ID)
+	require.Equal(t, 1, len(timers[0].History))
+	require.Equal(t, "failed", timers[0].History[0].Error)
+	require.True(t, timers[0].History[0].Triggered)
+	require.Nil(t, timers[1].History)
+
+	require.Equal(t, http.StatusNoContent, do(t, h, "DELETE", fmt.Sprintf("/timers/%d", t1.ID()), nil))
+	require.Equal(t, http.StatusNotFound, do(t, h, "DELETE", fmt.Sprintf("/timers/%d", t1.ID()), nil))
+	require.Equal(t, http.StatusBadRequest, do(t, h, "DELETE", "/timers/x", nil))
+	require.Equal(t, http.StatusNoContent, do(t, h, "DELETE", "/keys/k", nil))
+	require.Equal(t, http.StatusNotFound, do(t, h, "DELETE", "/keys/k", nil))
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pause", &status))
+	require.True(t, status.Paused)
+	require.True(t, tw.Paused())
+
+	// The expired timer held by Pause can be listed and canceled.
+	t3 := tw.TimeFunc(context.Background(), time.Now(), fn)
+	require.Eventually(t, func() bool { return tw.Held() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/timers", &timers))
+	require.Equal(t, t3.ID(), timers[0].ID)
+	require.True(t, timers[0].Held)
+	require.Equal(t, http.StatusNoContent, do(t, h, "DELETE", fmt.Sprintf("/timers/%d", t3.ID()), nil))
+	require.Equal(t, timewheel.ErrTimerClosed, t3.Err())
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/resume", &status))
+	require.False(t, status.Paused)
+
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, h, "GET", "/pause", nil))
+	require.Equal(t, http.StatusNotFound, do(t, h, "GET", "/unknown", nil))
+}
+
+func TestHandler_StripPrefix(t *testing.T) {
+	tw := timewheel.Default()
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/timewheel/", http.StripPrefix("/debug/timewheel", Handler(tw)))
+
+	var status Status
+	require.Equal(t, http.StatusOK, do(t, mux, "GET", "/debug/timewheel/", &status))
+	require.Equal(t, http.StatusOK, do(t, mux, "POST", "/debug/timewheel/pause", &status))
+	require.True(t, status.Paused)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// bucketItem is a bucket in bucketQueue.
+type bucketItem struct {
+	expiration int64
+	b          *bucket
+}
+
+// bucketHeap implements heap.Interface, ordered by expiration.
+type bucketHeap []bucketItem
+
+func (h bucketHeap) Len() int            { return len(h) }
+func (h bucketHeap) Less(i, j int) bool  { return h[i].expiration < h[j].expiration }
+func (h bucketHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *bucketHeap) Push(x interface{}) { *h = append(*h, x.(bucketItem)) }
+func (h *bucketHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = bucketItem{}
+	*h = old[:n-1]
+	return item
+}
+
+// bucketQueue is the priority queue of buckets used in manual mode, it's polled
+// by Advance instead of a goroutine.
+type bucketQueue struct {
+	mu    sync.Mutex
+	items bucketHeap
+}
+
+// offer adds the bucket b with expiration to the queue.
+func (q *bucketQueue) offer(expiration int64, b *bucket) {
+	q.mu.Lock()
+	heap.Push(&q.items, bucketItem{expiration: expiration, b: b})
+	q.mu.Unlock()
+}
+
+// peek returns the earliest expiration in the queue.
+func (q *bucketQueue) peek() (int64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return 0, false
+	}
+	return q.items[0].expiration, true
+}
+
+// poll removes and returns the earliest bucket if it's expired at now.
+func (q *bucketQueue) poll(now int64) (*bucket, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || q.items[0].expiration > now {
+		return nil, false
+	}
+	return heap.Pop(&q.items).(bucketItem).b, true
+}
+
+// Advance processes all buckets that are expired at now synchronously in manual
+// mode, the expired timers are dispatched to the Executor as usual. It returns the
+// number of dispatched timers.
+//
+// With the default Executor, the jobs run asynchronously after Advance returns. Use
+// the InlineExecutor to run the jobs in the invoker's goroutine before Advance returns,
+// or WithBatchHandler to receive the expired timers instead of running their jobs.
+// Use Poll to get the dispatched timers.
+//
+// Advance panics if the TimeWheel is not created with WithManualAdvance.
+func (tw *TimeWheel) Advance(now time.Time) int {
+	return tw.Poll(now, nil)
+}
+
+// Poll is like Advance, but also calls fn with each dispatched timer in order of
+// expiration, in the invoker's goroutine after the timer is dispatched. The timer
+// may have been rescheduled or closed by its job by then. A nil fn is ignored.
+//
+// Poll panics if the TimeWheel is not created with WithManualAdvance.
+func (tw *TimeWheel) Poll(now time.Time, fn func(t *Timer)) int {
+	if tw.manual == nil {
+		panic("timewheel: Advance and Poll require the manual mode")
+	}
+
+	var n int
+	nowMs := timeToMs(now)
+	for {
+		b, ok := tw.manual.poll(nowMs)
+		if !ok {
+			return n
+		}
+		tw.advance(b.getExpiration())
+		expired := tw.flush(b)
+		n += len(expired)
+		if fn != nil {
+			for _, t := range expired {
+				fn(t)
+			}
+		}
+	}
+}
+
+// NextDeadline returns the time when the next bucket expires in manual mode, i.e.
+// the invoker should call Advance at then. It returns false if there is no pending
+// bucket or the TimeWheel is not in manual mode.
+//
+// The deadline may be earlier than the expiration of any timer, since the timers in
+// the overflow wheels are moved to the lower wheels at the deadline.
+func (tw *TimeWheel) NextDeadline() (time.Time, bool) {
+	if tw.manual == nil {
+		return time.Time{}, false
+	}
+	expiration, ok := tw.manual.peek()
+	if !ok {
+		return time.Time{}, false
+	}
+	return msToTime(expiration).In(tw.location), true
+}
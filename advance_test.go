@@ -0,0 +1,129 @@
+package timewheel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_Advance(t *testing.T) {
+	tw := New(time.Millisecond, 8, WithManualAdvance(), WithExecutor(InlineExecutor{}))
+	tw.Start()
+	defer tw.Stop()
+
+	_, ok := tw.NextDeadline()
+	require.False(t, ok)
+
+	var fired []int
+	now := msToTime(tw.current)
+	for i, d := range []time.Duration{5, 3, 100, 1000} {
+		i := i
+		tw.TimeFunc(context.Background(), now.Add(d*time.Millisecond), func(ctx context.Context) error {
+			fired = append(fired, i)
+			return nil
+		})
+	}
+	var runs, cycles int
+	recurring := tw.ScheduleJob(context.Background(), ScheduleFunc(func(prev time.Time) time.Time {
+		cycles++
+		return now.Add(time.Millisecond * 50 * time.Duration(cycles))
+	}), JobFunc(func(ctx context.Context) error {
+		runs++
+		return nil
+	}))
+	defer recurring.Close()
+
+	deadline, ok := tw.NextDeadline()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Millisecond*3), deadline)
+
+	// Nothing is due.
+	require.Equal(t, 0, tw.Advance(now.Add(time.Millisecond*2)))
+	require.Nil(t, fired)
+
+	require.Equal(t, 2, tw.Advance(now.Add(time.Millisecond*5)))
+	require.Equal(t, []int{1, 0}, fired)
+
+	// Walks through the deadlines till the last timer fires.
+	for {
+		deadline, ok = tw.NextDeadline()
+		require.True(t, ok)
+		if deadline.After(now.Add(time.Second)) {
+			break
+		}
+		tw.Advance(deadline)
+	}
+	require.Equal(t, []int{1, 0, 2, 3}, fired)
+	require.Equal(t, 20, runs)
+	require.Equal(t, 1, tw.Len())
+}
+
+func TestTimeWheel_Poll(t *testing.T) {
+	tw := New(time.Millisecond, 8, WithManualAdvance())
+	tw.Start()
+	defer tw.Stop()
+
+	now := msToTime(tw.current)
+	fn := func(ctx context.Context) error { return nil }
+	t1 := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*3), fn)
+	t2 := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*5), fn)
+	t3 := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*100), fn)
+	defer t3.Close()
+
+	var fired []*Timer
+	n := tw.Poll(now.Add(time.Millisecond*10), func(t *Timer) { fired = append(fired, t) })
+	require.Equal(t, 2, n)
+	require.Equal(t, []*Timer{t1, t2}, fired)
+	<-t1.Done()
+	<-t2.Done()
+
+	// A nil fn is like Advance.
+	require.Equal(t, 1, tw.Poll(now.Add(time.Millisecond*100), nil))
+	<-t3.Done()
+	require.Equal(t, 0, tw.Len())
+}
+
+func TestTimeWheel_Advance_Panic(t *testing.T) {
+	tw := Default()
+	require.Panics(t, func() { tw.Advance(time.Now()) })
+	require.Panics(t, func() { tw.Poll(time.Now(), nil) })
+	_, ok := tw.NextDeadline()
+	require.False(t, ok)
+}
+
+func TestTimeWheel_Advance_Close(t *testing.T) {
+	tw := New(time.Millisecond, 8, WithManualAdvance(), WithExecutor(InlineExecutor{}))
+	tw.Start()
+	defer tw.Stop()
+
+	now := msToTime(tw.current)
+	fn := func(ctx context.Context) error { return nil }
+	other := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*5), fn)
+	var self *Timer
+	self = tw.TimeFunc(context.Background(), now.Add(time.Millisecond*5), func(ctx context.Context) error {
+		// The jobs run synchronously by Advance, closing the timers in the same
+		// bucket must not wait for the flush.
+		self.Close()
+		other.Close()
+		return nil
+	})
+	recurring := tw.ScheduleJob(context.Background(), Every(time.Millisecond*5), JobFunc(func(ctx context.Context) error {
+		tw.CancelByTag("none")
+		return nil
+	}))
+
+	doneC := make(chan int)
+	go func() { doneC <- tw.Advance(now.Add(time.Millisecond * 5)) }()
+	select {
+	case n := <-doneC:
+		require.GreaterOrEqual(t, n, 2)
+	case <-time.After(time.Second):
+		t.Fatal("Advance deadlocked")
+	}
+
+	recurring.Close()
+	<-self.Done()
+	require.Equal(t, 0, tw.Len())
+}
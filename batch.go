@@ -0,0 +1,78 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// dispatchBatch hands the expired timers to the batch handler in a new goroutine.
+// The timers are held until Resume if the TimeWheel is paused.
+func (tw *TimeWheel) dispatchBatch(timers []*Timer) {
+	var limited []*Timer
+	expired := timers[:0]
+	for _, t := range timers {
+		if !tw.expire(t) {
+			continue
+		}
+		// The quotas are kept by the TimeWheel that t belongs to, a timer beyond the
+		// MaxRunning of its tags is handed to the handler later as a batch of one.
+		t := t
+		owner := t.tw
+		if !owner.acquireRun(&quotaRun{t: t, at: t.expiration, fn: func() { owner.runBatch([]*Timer{t}) }}) {
+			continue
+		}
+		if owner.limited(t) {
+			limited = append(limited, t)
+		}
+		expired = append(expired, t)
+	}
+	if len(expired) == 0 {
+		return
+	}
+	go func() {
+		tw.runBatch(expired)
+		for _, t := range limited {
+			t.tw.release(t)
+		}
+	}()
+}
+
+// runBatch calls the batch handler with the expired timers, and finishes the
+// timers that have no next execution after it returns.
+func (tw *TimeWheel) runBatch(expired []*Timer) {
+	var finished []*Timer
+
+	timers := expired[:0]
+	for _, t := range expired {
+		if atomic.LoadInt32(&t.closed) == 1 {
+			// The timer has been closed before the handler is called.
+			t.resolve(ErrTimerClosed)
+			continue
+		}
+		timers = append(timers, t)
+		if t.sh == nil || !tw.reschedule(t) {
+			finished = append(finished, t)
+		}
+	}
+	if len(timers) == 0 {
+		return
+	}
+
+	err := tw.call(context.Background(), func(ctx context.Context) error {
+		tw.batch(timers)
+		return nil
+	})
+	if pe, ok := err.(*PanicError); ok {
+		tw.logger.Error("timewheel: batch handler panic", "timers", len(timers), "panic", pe.Value, "stack", string(pe.Stack))
+	}
+
+	for _, t := range finished {
+		tw.finish(t)
+		t.resolve(err)
+	}
+}
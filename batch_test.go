@@ -0,0 +1,80 @@
+package timewheel
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBatchHandler(t *testing.T) {
+	batchC := make(chan []*Timer, 16)
+	tw := New(time.Millisecond, 8, WithManualAdvance(), WithBatchHandler(func(expired []*Timer) {
+		batchC <- expired
+	}))
+	tw.Start()
+	defer tw.Stop()
+
+	now := msToTime(tw.current)
+	var ids []uint64
+	for i := 0; i < 100; i++ {
+		// The jobs are not called in batch mode.
+		timer := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*20), nil)
+		ids = append(ids, timer.ID())
+	}
+	later := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*30), nil)
+	recurring := tw.ScheduleJob(context.Background(), ScheduleFunc(func(prev time.Time) time.Time {
+		return prev.Add(time.Millisecond * 22)
+	}), nil)
+	defer recurring.Close()
+	require.Equal(t, 102, tw.Len())
+
+	// The timers in the overflow wheel are moved to the lower one.
+	for deadline, _ := tw.NextDeadline(); deadline.Before(now.Add(time.Millisecond * 20)); deadline, _ = tw.NextDeadline() {
+		require.Equal(t, 0, tw.Advance(deadline))
+	}
+	require.Equal(t, 0, len(batchC))
+
+	require.Equal(t, 100, tw.Advance(now.Add(time.Millisecond*20)))
+	batch := <-batchC
+	require.Equal(t, 100, len(batch))
+	var got []uint64
+	for _, timer := range batch {
+		got = append(got, timer.ID())
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	require.Equal(t, ids, got)
+
+	<-batch[0].Done()
+	require.NoError(t, batch[0].Err())
+	require.Eventually(t, func() bool { return tw.Len() == 2 }, time.Second, time.Millisecond)
+
+	// The recurring timer is resubmitted.
+	require.Equal(t, 1, tw.Advance(now.Add(time.Millisecond*29)))
+	require.Equal(t, []*Timer{recurring}, <-batchC)
+
+	// The expired timers are held while paused.
+	tw.Pause()
+	require.Equal(t, 1, tw.Advance(now.Add(time.Millisecond*30)))
+	require.Equal(t, 1, tw.Held())
+	require.Equal(t, 0, len(batchC))
+	tw.Resume()
+	require.Equal(t, []*Timer{later}, <-batchC)
+}
+
+func TestWithBatchHandler_Panic(t *testing.T) {
+	logger := new(testLogger)
+	tw := Default(WithLogger(logger), WithBatchHandler(func(expired []*Timer) {
+		panic("boom")
+	}))
+	tw.Start()
+	defer tw.Stop()
+
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), nil)
+	<-timer.Done()
+	_, ok := timer.Err().(*PanicError)
+	require.True(t, ok)
+	require.True(t, logger.has("ERROR timewheel: batch handler panic"))
+}
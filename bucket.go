@@ -58,7 +58,7 @@ func (b *bucket) delete(t *Timer) bool {
 	} else if t.element == nil {
 		// If delete is called after following cases happens:
 		//   1. the timer t add by tw.AfterFunc.
-		//   2. the next time is zero in tw.scheduleFunc.
+		//   2. the next time is zero in tw.reschedule.
 		// In either cases, the timer t not in TimeWheel and is nil (set by b.flush),
 		// and it can be considered as a successful deletion.
 	} else {
@@ -72,6 +72,46 @@ func (b *bucket) delete(t *Timer) bool {
 	return ok
 }
 
+// detach removes t from b to move it to another bucket, it's called by tw.retry.
+//
+// return false indicates t is not in b, e.g. it has been flushed from b.
+func (b *bucket) detach(t *Timer) bool {
+	b.flushMu.Lock()
+	b.mu.Lock()
+
+	ok := t.getBucket() == b && t.element != nil
+	if ok {
+		b.timers.Remove(t.element)
+		t.setBucket(nil)
+		t.element = nil
+	}
+
+	b.mu.Unlock()
+	b.flushMu.Unlock()
+	return ok
+}
+
+// len returns the number of timers in b.
+func (b *bucket) len() int {
+	b.mu.Lock()
+	n := b.timers.Len()
+	b.mu.Unlock()
+	return n
+}
+
+// walk calls fn for each timer in b with b locked, stops if fn returns false.
+func (b *bucket) walk(fn func(t *Timer) bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for e := b.timers.Front(); e != nil; e = e.Next() {
+		if !fn(e.Value.(*Timer)) {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *bucket) flush(submit func(*Timer)) {
 	b.flushMu.Lock()
 	b.mu.Lock()
@@ -92,7 +132,7 @@ func (b *bucket) flush(submit func(*Timer)) {
 
 		// The timer t may not re-enqueue in the following cases:
 		//   1. the timer add by tw.AfterFunc.
-		//   2. the next time is zero in tw.scheduleFunc.
+		//   2. the next time is zero in tw.reschedule.
 		// Thus, set the t.element to nil before submit to prevents unexpected when call t.Close.
 		t.element = nil
 
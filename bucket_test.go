@@ -95,3 +95,26 @@ func Test_bucket_flush_elapse(t *testing.T) {
 		})
 	}
 }
+
+func Test_bucket_walk(t *testing.T) {
+	b := newBucket()
+
+	n := 5
+	for i := 0; i < n; i++ {
+		b.insert(&Timer{id: uint64(i)})
+	}
+
+	var ids []uint64
+	require.True(t, b.walk(func(timer *Timer) bool {
+		ids = append(ids, timer.id)
+		return true
+	}))
+	require.Equal(t, []uint64{0, 1, 2, 3, 4}, ids)
+
+	ids = ids[:0]
+	require.False(t, b.walk(func(timer *Timer) bool {
+		ids = append(ids, timer.id)
+		return len(ids) < 2
+	}))
+	require.Equal(t, []uint64{0, 1}, ids)
+}
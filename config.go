@@ -0,0 +1,228 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxConfigSize is the max Size of Config, it bounds the memory of buckets allocated
+// for each level of wheels.
+const MaxConfigSize = 1 << 20
+
+// Duration is a time.Duration that is encoded as a string like "1.5s" in text
+// formats, e.g. JSON and YAML, thus it can be written in configuration files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ConfigError is returned by NewWithConfig and Config.Validate for an invalid field.
+type ConfigError struct {
+	Field  string // The name of the field in Config.
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("timewheel: invalid config %s: %s", e.Field, e.Reason)
+}
+
+// Config is the configuration of TimeWheel for NewWithConfig. The zero value of each
+// field means the default. The fields of plain values can be loaded from JSON or
+// YAML, the others must be set in code.
+type Config struct {
+	// Tick is the time span of each unit, it must be >= 1ms and a multiple of 1ms.
+	// The default is 1ms.
+	Tick Duration `json:"tick,omitempty" yaml:"tick,omitempty"`
+
+	// Size is the size of time wheel of each layer, it must be >= 2 and <= MaxConfigSize.
+	// The default is 32.
+	Size int64 `json:"size,omitempty" yaml:"size,omitempty"`
+
+	// Location is the name of time zone in the IANA Time Zone database, e.g.
+	// "Asia/Shanghai", it's used by the schedules. The default is "Local".
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+
+	// ManualAdvance enables the manual mode, in which the clock of TimeWheel is
+	// driven by Advance instead of the wall clock. See WithManualAdvance. There is
+	// no pluggable clock for the other reads of the current time, e.g. the first
+	// time of schedules.
+	ManualAdvance bool `json:"manual_advance,omitempty" yaml:"manual_advance,omitempty"`
+
+	// MaxTimers is the limit of pending timers, see WithMaxTimers.
+	MaxTimers int `json:"max_timers,omitempty" yaml:"max_timers,omitempty"`
+
+	// TagQuotas are the quotas of tags, see WithTagQuota.
+	TagQuotas map[string]Quota `json:"tag_quotas,omitempty" yaml:"tag_quotas,omitempty"`
+
+	// History is the max number of runs kept for each timer, see WithHistory.
+	History int `json:"history,omitempty" yaml:"history,omitempty"`
+
+	// JobTimeout and HangGrace, see WithJobTimeout and WithHangGrace.
+	JobTimeout Duration `json:"job_timeout,omitempty" yaml:"job_timeout,omitempty"`
+	HangGrace  Duration `json:"hang_grace,omitempty" yaml:"hang_grace,omitempty"`
+
+	// MisfirePolicy is the policy of the recovered timers, see WithMisfirePolicy.
+	MisfirePolicy MisfirePolicy `json:"misfire_policy,omitempty" yaml:"misfire_policy,omitempty"`
+
+	// The executor, store and hooks, nil means the default.
+	Executor    Executor    `json:"-" yaml:"-"`
+	Store       Store       `json:"-" yaml:"-"`
+	Registry    *Registry   `json:"-" yaml:"-"`
+	Logger      Logger      `json:"-" yaml:"-"`
+	Tracer      Tracer      `json:"-" yaml:"-"`
+	MetricsSink MetricsSink `json:"-" yaml:"-"`
+	Observers   []Observer  `json:"-" yaml:"-"`
+
+	// Options are applied after the above fields.
+	Options []Option `json:"-" yaml:"-"`
+}
+
+// Validate checks the fields of c, it returns a *ConfigError for the first invalid one.
+func (c *Config) Validate() error {
+	if c.Tick != 0 && time.Duration(c.Tick) < time.Millisecond {
+		return &ConfigError{Field: "Tick", Reason: fmt.Sprintf("%s is less than 1ms", c.Tick)}
+	}
+	if time.Duration(c.Tick)%time.Millisecond != 0 {
+		return &ConfigError{Field: "Tick", Reason: fmt.Sprintf("%s is not a multiple of 1ms", c.Tick)}
+	}
+	if c.Size != 0 && c.Size < 2 {
+		// The overflow wheels of size 1 never cover more time than the lower ones.
+		return &ConfigError{Field: "Size", Reason: fmt.Sprintf("%d is less than 2", c.Size)}
+	}
+	if c.Size > MaxConfigSize {
+		return &ConfigError{Field: "Size", Reason: fmt.Sprintf("%d is greater than %d", c.Size, MaxConfigSize)}
+	}
+	tick, size := durationToMs(defaultTick), defaultSize
+	if c.Tick != 0 {
+		tick = durationToMs(time.Duration(c.Tick))
+	}
+	if c.Size != 0 {
+		size = c.Size
+	}
+	// The span of each level of wheels, tick*size^level, must not overflow until it
+	// covers the max expiration.
+	span := tick
+	for span < maxExpirationNs/int64(time.Millisecond) {
+		if span > math.MaxInt64/size {
+			return &ConfigError{Field: "Tick", Reason: fmt.Sprintf("%s with size %d overflows the span of wheels", c.Tick, size)}
+		}
+		span *= size
+	}
+	if c.Location != "" {
+		if _, err := time.LoadLocation(c.Location); err != nil {
+			return &ConfigError{Field: "Location", Reason: fmt.Sprintf("unknown time zone %q", c.Location)}
+		}
+	}
+	if c.MaxTimers < 0 {
+		return &ConfigError{Field: "MaxTimers", Reason: "must not be negative"}
+	}
+	for tag, q := range c.TagQuotas {
+		if tag == "" {
+			return &ConfigError{Field: "TagQuotas", Reason: "tag must not be empty"}
+		}
+		if q.MaxPending < 0 || q.MaxRunning < 0 {
+			return &ConfigError{Field: "TagQuotas", Reason: fmt.Sprintf("quota of tag %q must not be negative", tag)}
+		}
+	}
+	if c.History < 0 {
+		return &ConfigError{Field: "History", Reason: "must not be negative"}
+	}
+	if c.JobTimeout < 0 {
+		return &ConfigError{Field: "JobTimeout", Reason: "must not be negative"}
+	}
+	if c.HangGrace < 0 {
+		return &ConfigError{Field: "HangGrace", Reason: "must not be negative"}
+	}
+	if c.MisfirePolicy != MisfireFireNow && c.MisfirePolicy != MisfireDiscard {
+		return &ConfigError{Field: "MisfirePolicy", Reason: fmt.Sprintf("unknown policy %d", c.MisfirePolicy)}
+	}
+	return nil
+}
+
+// NewWithConfig is like New, but creates the TimeWheel by c and returns a *ConfigError
+// instead of panicking if c is invalid. It's suitable for the configuration supplied
+// by users, e.g. read from files.
+func NewWithConfig(c Config) (*TimeWheel, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	tick, size := defaultTick, defaultSize
+	if c.Tick != 0 {
+		tick = time.Duration(c.Tick)
+	}
+	if c.Size != 0 {
+		size = c.Size
+	}
+
+	var opts []Option
+	if c.Location != "" {
+		loc, _ := time.LoadLocation(c.Location)
+		opts = append(opts, WithTimezone(loc))
+	}
+	if c.ManualAdvance {
+		opts = append(opts, WithManualAdvance())
+	}
+	if c.MaxTimers != 0 {
+		opts = append(opts, WithMaxTimers(c.MaxTimers))
+	}
+	for tag, q := range c.TagQuotas {
+		opts = append(opts, WithTagQuota(tag, q))
+	}
+	if c.History != 0 {
+		opts = append(opts, WithHistory(c.History))
+	}
+	if c.JobTimeout != 0 {
+		opts = append(opts, WithJobTimeout(time.Duration(c.JobTimeout)))
+	}
+	if c.HangGrace != 0 {
+		opts = append(opts, WithHangGrace(time.Duration(c.HangGrace)))
+	}
+	opts = append(opts, WithMisfirePolicy(c.MisfirePolicy))
+	if c.Executor != nil {
+		opts = append(opts, WithExecutor(c.Executor))
+	}
+	if c.Store != nil {
+		opts = append(opts, WithStore(c.Store))
+	}
+	if c.Registry != nil {
+		opts = append(opts, WithRegistry(c.Registry))
+	}
+	if c.Logger != nil {
+		opts = append(opts, WithLogger(c.Logger))
+	}
+	if c.Tracer != nil {
+		opts = append(opts, WithTracer(c.Tracer))
+	}
+	if c.MetricsSink != nil {
+		opts = append(opts, WithMetricsSink(c.MetricsSink))
+	}
+	for _, o := range c.Observers {
+		opts = append(opts, WithObserver(o))
+	}
+	opts = append(opts, c.Options...)
+
+	return New(tick, size, opts...), nil
+}
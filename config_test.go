@@ -0,0 +1,100 @@
+package timewheel
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	var c Config
+	err := json.Unmarshal([]byte(`{
+		"tick": "10ms",
+		"size": 64,
+		"location": "UTC",
+		"manual_advance": true,
+		"max_timers": 100,
+		"tag_quotas": {"tenant-a": {"max_pending": 10, "max_running": 2}},
+		"history": 5,
+		"job_timeout": "1s",
+		"misfire_policy": "discard"
+	}`), &c)
+	require.Nil(t, err)
+	require.Equal(t, Duration(10*time.Millisecond), c.Tick)
+	require.Equal(t, MisfireDiscard, c.MisfirePolicy)
+
+	c.Executor = InlineExecutor{}
+	tw, err := NewWithConfig(c)
+	require.Nil(t, err)
+	require.Equal(t, int64(10), tw.tick)
+	require.Equal(t, int64(64), tw.size)
+	require.Equal(t, time.UTC, tw.location)
+	require.NotNil(t, tw.manual)
+	require.Equal(t, int64(100), tw.maxTimers)
+	require.Equal(t, Quota{MaxPending: 10, MaxRunning: 2}, tw.quotas["tenant-a"].Quota)
+	require.Equal(t, 5, tw.historySize)
+	require.Equal(t, time.Second, tw.jobTimeout)
+	require.Equal(t, MisfireDiscard, tw.misfire)
+	require.Equal(t, InlineExecutor{}, tw.executor)
+	require.Equal(t, 100, tw.Stats().MaxTimers)
+
+	b, err := json.Marshal(&c)
+	require.Nil(t, err)
+	require.Contains(t, string(b), `"tick":"10ms"`)
+	require.Contains(t, string(b), `"misfire_policy":"discard"`)
+}
+
+func TestNewWithConfig_Default(t *testing.T) {
+	tw, err := NewWithConfig(Config{})
+	require.Nil(t, err)
+	require.Equal(t, durationToMs(defaultTick), tw.tick)
+	require.Equal(t, defaultSize, tw.size)
+	require.Equal(t, time.Local, tw.location)
+	require.Nil(t, tw.manual)
+}
+
+func TestNewWithConfig_Invalid(t *testing.T) {
+	cases := []struct {
+		config Config
+		field  string
+	}{
+		{Config{Tick: Duration(time.Microsecond)}, "Tick"},
+		{Config{Tick: Duration(time.Microsecond * 1500)}, "Tick"},
+		{Config{Tick: Duration(time.Millisecond * 9e12), Size: MaxConfigSize}, "Tick"},
+		{Config{Size: -1}, "Size"},
+		{Config{Size: 1}, "Size"},
+		{Config{Size: 1 << 62}, "Size"},
+		{Config{Location: "Nowhere/City"}, "Location"},
+		{Config{MaxTimers: -1}, "MaxTimers"},
+		{Config{TagQuotas: map[string]Quota{"": {}}}, "TagQuotas"},
+		{Config{TagQuotas: map[string]Quota{"a": {MaxRunning: -1}}}, "TagQuotas"},
+		{Config{History: -1}, "History"},
+		{Config{JobTimeout: -1}, "JobTimeout"},
+		{Config{HangGrace: -1}, "HangGrace"},
+		{Config{MisfirePolicy: 9}, "MisfirePolicy"},
+	}
+	for _, c := range cases {
+		tw, err := NewWithConfig(c.config)
+		require.Nil(t, tw)
+
+		var ce *ConfigError
+		require.True(t, errors.As(err, &ce), c.field)
+		require.Equal(t, c.field, ce.Field)
+	}
+
+	// The smallest and largest values that are valid.
+	tw, err := NewWithConfig(Config{Tick: Duration(time.Millisecond), Size: 2})
+	require.Nil(t, err)
+	require.Equal(t, int64(2), tw.size)
+
+	tw, err = NewWithConfig(Config{Tick: Duration(time.Hour), Size: MaxConfigSize, Location: "UTC"})
+	require.Nil(t, err)
+	require.Equal(t, int64(MaxConfigSize), tw.size)
+
+	var c Config
+	require.NotNil(t, json.Unmarshal([]byte(`{"tick": "fast"}`), &c))
+	require.NotNil(t, json.Unmarshal([]byte(`{"misfire_policy": "later"}`), &c))
+}
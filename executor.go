@@ -0,0 +1,53 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+// Executor executes the jobs of expired timers.
+//
+// If an Executor queues the jobs before running them, it may implements
+// the Len() int method to report the queue length in Stats.
+type Executor interface {
+	// Execute runs fn, which calls the job of the expired timer t. It's called by
+	// the TimeWheel's goroutine, so it must not block for long.
+	Execute(t *Timer, fn func())
+}
+
+// goExecutor is the default Executor.
+type goExecutor struct{}
+
+// Execute implements Executor.
+//
+// Like the standard time.AfterFunc (https://golang.org/pkg/time/#AfterFunc),
+// always execute the timer's jobFunc in its own goroutine.
+func (goExecutor) Execute(t *Timer, fn func()) {
+	go fn()
+}
+
+// InlineExecutor is an Executor that runs the jobs in the goroutine that dispatches
+// them, i.e. the TimeWheel's goroutine, or the invoker of Advance in manual mode.
+// The jobs must be fast, since they block the TimeWheel.
+type InlineExecutor struct{}
+
+// Execute implements Executor.
+func (InlineExecutor) Execute(t *Timer, fn func()) {
+	fn()
+}
+
+// scheduledExecutor is implemented by the Executors that order the jobs by the time
+// they are scheduled at. The TimeWheel passes the time instead of letting them read
+// the timer's expiration, which is updated concurrently once a recurring job starts.
+type scheduledExecutor interface {
+	executeAt(t *Timer, at int64, fn func())
+}
+
+// handoff hands fn, which runs the job of t scheduled at (in milliseconds), to the Executor.
+func (tw *TimeWheel) handoff(t *Timer, at int64, fn func()) {
+	if e, ok := tw.executor.(scheduledExecutor); ok {
+		e.executeAt(t, at, fn)
+		return
+	}
+	tw.executor.Execute(t, fn)
+}
@@ -0,0 +1,58 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// queueExecutor queues the jobs until drain is called.
+type queueExecutor struct {
+	mu    sync.Mutex
+	queue []func()
+}
+
+func (e *queueExecutor) Execute(t *Timer, fn func()) {
+	e.mu.Lock()
+	e.queue = append(e.queue, fn)
+	e.mu.Unlock()
+}
+
+func (e *queueExecutor) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.queue)
+}
+
+func (e *queueExecutor) drain() {
+	e.mu.Lock()
+	queue := e.queue
+	e.queue = nil
+	e.mu.Unlock()
+
+	for _, fn := range queue {
+		fn()
+	}
+}
+
+func TestWithExecutor(t *testing.T) {
+	executor := new(queueExecutor)
+	tw := Default(WithExecutor(executor))
+	tw.Start()
+	defer tw.Stop()
+
+	var called bool
+	tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	require.Equal(t, 1, tw.Stats().ExecutorQueue)
+	require.False(t, called)
+
+	executor.drain()
+	require.True(t, called)
+	require.Equal(t, 0, tw.Stats().ExecutorQueue)
+}
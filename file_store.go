@@ -0,0 +1,259 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// compactThreshold is the minimum number of stale entries in log before compaction.
+const compactThreshold = 1024
+
+// ErrStoreClosed is returned when operates on a closed FileStore.
+var ErrStoreClosed = errors.New("timewheel: store closed")
+
+// logFile is the log file of FileStore, it's an interface for testing.
+type logFile interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// logEntry is an entry of the FileStore's append-only log.
+type logEntry struct {
+	Op     string  `json:"op"` // "save" or "delete".
+	ID     uint64  `json:"id,omitempty"`
+	Record *Record `json:"record,omitempty"`
+}
+
+// FileStore is a durable Store based on an append-only log file. Every change is
+// appended to the log and synced to disk before returning. The log is compacted
+// by rewriting the live records into a new file when the stale entries exceed
+// the live ones.
+type FileStore struct {
+	path    string
+	file    logFile
+	size    int64              // The size of log file, i.e. the offset of next entry.
+	err     error              // The error that makes the log unrecoverable, if any.
+	records map[uint64]*Record // The live records.
+	stale   int                // The number of stale entries in log.
+	mu      *sync.Mutex
+}
+
+// OpenFileStore opens the FileStore of the log file path, the file is created if not exists.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:    path,
+		file:    nil,
+		size:    0,
+		err:     nil,
+		records: make(map[uint64]*Record),
+		stale:   0,
+		mu:      new(sync.Mutex),
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	// Compacts once at open to drop the stale entries of the previous run.
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay rebuilds the live records from the log file.
+func (s *FileStore) replay() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("timewheel: open store: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// The last line without newline is a torn write of crash, ignore it.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("timewheel: read store: %w", err)
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("timewheel: corrupted store: %w", err)
+		}
+		switch {
+		case entry.Op == "save" && entry.Record != nil:
+			s.records[entry.Record.ID] = entry.Record
+		case entry.Op == "delete":
+			delete(s.records, entry.ID)
+		default:
+			return fmt.Errorf("timewheel: corrupted store: unknown entry %q", line)
+		}
+	}
+}
+
+// compact rewrites the live records into a new log file and replaces the old one.
+func (s *FileStore) compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("timewheel: compact store: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range s.records {
+		if err = enc.Encode(&logEntry{Op: "save", Record: rec}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	var size int64
+	if err == nil {
+		size, err = f.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("timewheel: compact store: %w", err)
+	}
+
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		s.file = nil
+		return fmt.Errorf("timewheel: compact store: %w", err)
+	}
+	s.file = file
+	s.size = size
+	s.stale = 0
+	return nil
+}
+
+// append writes the entry to the end of log and syncs it to disk. If it fails, the
+// log is truncated back to the previous entry, thus a partial entry never remains
+// in the middle of log. If the truncation also fails, the store refuses the later
+// writes with the error since the log can not be replayed any more.
+func (s *FileStore) append(entry *logEntry) error {
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	if s.err != nil {
+		return s.err
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	_, err = s.file.Write(b)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		if tErr := s.file.Truncate(s.size); tErr != nil {
+			s.err = fmt.Errorf("timewheel: store failed: %w", tErr)
+		}
+		return err
+	}
+	s.size += int64(len(b))
+	return nil
+}
+
+// Save implements Store.
+func (s *FileStore) Save(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(&logEntry{Op: "save", Record: rec}); err != nil {
+		return err
+	}
+	if _, ok := s.records[rec.ID]; ok {
+		s.stale++
+	}
+	cp := *rec
+	s.records[rec.ID] = &cp
+	return s.maybeCompact()
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[id]; !ok {
+		return nil
+	}
+	if err := s.append(&logEntry{Op: "delete", ID: id}); err != nil {
+		return err
+	}
+	delete(s.records, id)
+	s.stale += 2 // Both the save and delete entries are stale.
+	return s.maybeCompact()
+}
+
+func (s *FileStore) maybeCompact() error {
+	if s.stale < compactThreshold || s.stale < len(s.records) {
+		return nil
+	}
+	return s.compact()
+}
+
+// LoadAll implements Store. The records are sorted by ID.
+func (s *FileStore) LoadAll() ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil, ErrStoreClosed
+	}
+	records := make([]*Record, 0, len(s.records))
+	for _, rec := range s.records {
+		cp := *rec
+		records = append(records, &cp)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// Close closes the log file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,151 @@
+package timewheel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timers.log")
+
+	s, err := OpenFileStore(path)
+	require.Nil(t, err)
+
+	require.Nil(t, s.Save(&Record{ID: 1, JobType: "a", Payload: []byte("p1"), Expiration: 100}))
+	require.Nil(t, s.Save(&Record{ID: 2, JobType: "b", Expiration: 200}))
+	require.Nil(t, s.Save(&Record{ID: 1, JobType: "a", Payload: []byte("p1"), Expiration: 300}))
+	require.Nil(t, s.Delete(2))
+	require.Nil(t, s.Delete(3))
+	require.Nil(t, s.Close())
+
+	// Reopen and replay.
+	s, err = OpenFileStore(path)
+	require.Nil(t, err)
+	defer s.Close()
+
+	records, err := s.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, []*Record{{ID: 1, JobType: "a", Payload: []byte("p1"), Expiration: 300}}, records)
+}
+
+func TestFileStore_TornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timers.log")
+
+	s, err := OpenFileStore(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Save(&Record{ID: 1, JobType: "a", Expiration: 100}))
+	require.Nil(t, s.Close())
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.Nil(t, err)
+	_, err = f.WriteString(`{"op":"save","record":{"id":2,`)
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+
+	s, err = OpenFileStore(path)
+	require.Nil(t, err)
+	defer s.Close()
+
+	records, err := s.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, uint64(1), records[0].ID)
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timers.log")
+
+	s, err := OpenFileStore(path)
+	require.Nil(t, err)
+	defer s.Close()
+
+	for i := 0; i < compactThreshold; i++ {
+		require.Nil(t, s.Save(&Record{ID: uint64(i), JobType: "a"}))
+		require.Nil(t, s.Delete(uint64(i)))
+	}
+	require.Less(t, s.stale, compactThreshold)
+
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	require.Less(t, info.Size(), int64(compactThreshold*10))
+}
+
+func TestFileStore_Closed(t *testing.T) {
+	s, err := OpenFileStore(filepath.Join(t.TempDir(), "timers.log"))
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+
+	require.Equal(t, ErrStoreClosed, s.Save(&Record{ID: 1}))
+	_, err = s.LoadAll()
+	require.Equal(t, ErrStoreClosed, err)
+}
+
+// failingFile writes a half of the bytes and fails if fail is set, and fails to
+// truncate if failTruncate is set.
+type failingFile struct {
+	*os.File
+	fail         bool
+	failTruncate bool
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("read-only")
+	}
+	return f.File.Truncate(size)
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if f.fail {
+		n, _ := f.File.Write(b[:len(b)/2])
+		return n, errors.New("disk full")
+	}
+	return f.File.Write(b)
+}
+
+func TestFileStore_WriteFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timers.log")
+
+	s, err := OpenFileStore(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Save(&Record{ID: 1, JobType: "a", Expiration: 100}))
+
+	f := &failingFile{File: s.file.(*os.File), fail: true}
+	s.file = f
+	require.Equal(t, "disk full", s.Save(&Record{ID: 2, JobType: "b", Expiration: 200}).Error())
+
+	// The partial entry is truncated, thus the later entries are still readable.
+	f.fail = false
+	require.Nil(t, s.Save(&Record{ID: 3, JobType: "c", Expiration: 300}))
+	require.Nil(t, s.Close())
+
+	s, err = OpenFileStore(path)
+	require.Nil(t, err)
+	defer s.Close()
+
+	records, err := s.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, uint64(1), records[0].ID)
+	require.Equal(t, uint64(3), records[1].ID)
+}
+
+func TestFileStore_TruncateFailure(t *testing.T) {
+	s, err := OpenFileStore(filepath.Join(t.TempDir(), "timers.log"))
+	require.Nil(t, err)
+	defer s.Close()
+
+	f := &failingFile{File: s.file.(*os.File), fail: true, failTruncate: true}
+	s.file = f
+	require.Equal(t, "disk full", s.Save(&Record{ID: 1}).Error())
+
+	// The store refuses the later writes since the log is torn.
+	f.fail = false
+	err = s.Save(&Record{ID: 2})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "read-only")
+}
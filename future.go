@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"sync/atomic"
+)
+
+// Done returns a channel that's closed when t is resolved, i.e. its last job run
+// has completed, or it has been closed before that. For the one-shot timer, it's
+// resolved after its job returns, even if it's closed while the job is running.
+//
+// Done returns nil for the timer that is not created by a TimeWheel.
+func (t *Timer) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns nil if Done is not yet closed. After that, Err returns the error of
+// the last job run, which is a *PanicError if the job panicked, or ErrTimerClosed
+// if t is closed while it's pending, see TimeWheel.Len.
+//
+// The runs started by Trigger do not resolve the timer.
+func (t *Timer) Err() error {
+	if atomic.LoadInt32(&t.resolved) != 2 {
+		return nil
+	}
+	return t.err
+}
+
+// resolve sets the result of t and closes its done channel, only the first
+// call takes effect.
+func (t *Timer) resolve(err error) {
+	if !atomic.CompareAndSwapInt32(&t.resolved, 0, 1) {
+		return
+	}
+	t.err = err
+	atomic.StoreInt32(&t.resolved, 2)
+	if t.done != nil {
+		close(t.done)
+	}
+}
@@ -0,0 +1,109 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer_Done(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*5), func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	require.Nil(t, t1.Err())
+	<-t1.Done()
+	require.Equal(t, "failed", t1.Err().Error())
+
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) error {
+		panic("boom")
+	})
+	<-t2.Done()
+	var pe *PanicError
+	require.True(t, errors.As(t2.Err(), &pe))
+	require.Equal(t, "boom", pe.Value)
+
+	t3 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) error {
+		return nil
+	})
+	<-t3.Done()
+	require.NoError(t, t3.Err())
+
+	// Triggered runs don't resolve the timer.
+	t4 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+	require.NoError(t, t4.Trigger(context.Background()))
+	select {
+	case <-t4.Done():
+		t.Fatal("resolved by trigger")
+	default:
+	}
+	t4.Close()
+	<-t4.Done()
+	require.Equal(t, ErrTimerClosed, t4.Err())
+}
+
+func TestTimer_Done_Close(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	// Closed while the job is running, it's resolved after the job returns.
+	startC := make(chan struct{})
+	doneC := make(chan struct{})
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) error {
+		close(startC)
+		<-doneC
+		return ctx.Err()
+	})
+	<-startC
+	t1.Close()
+	select {
+	case <-t1.Done():
+		t.Fatal("resolved before the job returns")
+	case <-time.After(time.Millisecond * 10):
+	}
+	close(doneC)
+	<-t1.Done()
+	require.Equal(t, context.Canceled, t1.Err())
+
+	// Recurring timer.
+	t2 := tw.ScheduleJob(context.Background(), Every(time.Hour), JobFunc(func(ctx context.Context) error { return nil }))
+	t2.Close()
+	<-t2.Done()
+	require.Equal(t, ErrTimerClosed, t2.Err())
+
+	// No time is scheduled.
+	t3 := tw.ScheduleJob(context.Background(), ScheduleFunc(func(time.Time) time.Time { return time.Time{} }),
+		JobFunc(func(ctx context.Context) error { return nil }))
+	<-t3.Done()
+	require.NoError(t, t3.Err())
+
+	require.Nil(t, (&Timer{}).Done())
+}
+
+func TestTimer_Done_Recurring(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	n := 0
+	timer := tw.ScheduleJob(context.Background(), ScheduleFunc(func(prev time.Time) time.Time {
+		if n >= 3 {
+			return time.Time{}
+		}
+		n++
+		return prev.Add(time.Millisecond * 5)
+	}), JobFunc(func(ctx context.Context) error {
+		return errors.New("failed")
+	}), WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	<-timer.Done()
+	require.Equal(t, "failed", timer.Err().Error())
+	require.Equal(t, 0, tw.Len())
+}
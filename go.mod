@@ -1,8 +1,15 @@
 module github.com/yu31/timewheel-go
 
-go 1.15
+go 1.18
 
 require (
 	github.com/stretchr/testify v1.7.0
 	github.com/yu31/dqueue-go v0.0.0-20230528150015-43c9e98894cf
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yu31/structs-go v0.0.0-20230528144825-8e5b93bbfcb1 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
@@ -0,0 +1,66 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import "sync"
+
+// Group is a set of timers that are closed together, e.g. all timers of a connection.
+// The timers join a group by the WithGroup option, and leave it once they are closed
+// or their last execution has completed.
+type Group struct {
+	timers map[*Timer]struct{}
+	closed bool
+	mu     *sync.Mutex
+}
+
+// NewGroup creates an empty Group.
+func NewGroup() *Group {
+	return &Group{
+		timers: make(map[*Timer]struct{}),
+		closed: false,
+		mu:     new(sync.Mutex),
+	}
+}
+
+// add adds t to g, return false if g has been closed.
+func (g *Group) add(t *Timer) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+	g.timers[t] = struct{}{}
+	return true
+}
+
+func (g *Group) remove(t *Timer) {
+	g.mu.Lock()
+	delete(g.timers, t)
+	g.mu.Unlock()
+}
+
+// Len returns the number of pending timers in g.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	n := len(g.timers)
+	g.mu.Unlock()
+	return n
+}
+
+// Close closes all timers in g. The timers that join g after Close are closed
+// immediately, thus no timer escapes from a closed group under concurrency.
+func (g *Group) Close() {
+	g.mu.Lock()
+	g.closed = true
+	timers := g.timers
+	g.timers = make(map[*Timer]struct{})
+	g.mu.Unlock()
+
+	for t := range timers {
+		t.Close()
+	}
+}
@@ -0,0 +1,72 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	fn := JobFunc(func(ctx context.Context) error { return nil })
+	at := time.Now().Add(time.Hour)
+
+	g := NewGroup()
+	t1 := tw.TimeFunc(context.Background(), at, fn, WithGroup(g))
+	t2 := tw.ScheduleJob(context.Background(), Every(time.Hour), fn, WithGroup(g))
+	t3 := tw.Upsert(context.Background(), "k", at, fn, WithGroup(g))
+	require.Equal(t, 3, g.Len())
+
+	t3.Close()
+	require.Equal(t, 2, g.Len())
+
+	g.Close()
+	require.Equal(t, 0, g.Len())
+	require.NotNil(t, t1.ctxCancel.Err())
+	require.NotNil(t, t2.ctxCancel.Err())
+
+	// The timer joins a closed group is closed immediately.
+	t4 := tw.TimeFunc(context.Background(), at, fn, WithGroup(g))
+	require.NotNil(t, t4.ctxCancel.Err())
+	require.Nil(t, t4.getBucket())
+	require.Equal(t, 0, g.Len())
+}
+
+func TestGroup_Close_Concurrent(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	g := NewGroup()
+	fn := JobFunc(func(ctx context.Context) error { return nil })
+
+	mu := new(sync.Mutex)
+	var timers []*Timer
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				timer := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), fn, WithGroup(g))
+				mu.Lock()
+				timers = append(timers, timer)
+				mu.Unlock()
+			}
+		}()
+	}
+	g.Close()
+	wg.Wait()
+
+	for _, timer := range timers {
+		require.NotNil(t, timer.ctxCancel.Err())
+	}
+	require.Equal(t, 0, g.Len())
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"time"
+)
+
+// Run describes a completed run of a timer's job.
+type Run struct {
+	Scheduled time.Time     // The expiration that the run is scheduled at, or the trigger time.
+	Start     time.Time     // The actual start time.
+	Duration  time.Duration // The duration of the run.
+	Err       error         // The error returned by the job, or *PanicError.
+	Panicked  bool          // Whether the job panicked.
+	Triggered bool          // Whether the run is started by Timer.Trigger.
+}
+
+// history is a ring buffer of the last runs of a timer, it must be used with the
+// timerStats locked.
+type history struct {
+	runs []Run
+	next int // The index to write the next run once runs is full.
+	size int // The max number of runs, 0 means disabled.
+}
+
+// add records run, the oldest run is dropped if the buffer is full.
+func (h *history) add(run Run) {
+	if h.size <= 0 {
+		return
+	}
+	if len(h.runs) < h.size {
+		h.runs = append(h.runs, run)
+		return
+	}
+	h.runs[h.next] = run
+	h.next = (h.next + 1) % h.size
+}
+
+// list returns a copy of the runs, from the oldest to the newest.
+func (h *history) list() []Run {
+	if len(h.runs) == 0 {
+		return nil
+	}
+	runs := make([]Run, 0, len(h.runs))
+	runs = append(runs, h.runs[h.next:]...)
+	runs = append(runs, h.runs[:h.next]...)
+	return runs
+}
+
+// History returns the last runs of t's job from the oldest to the newest, the number
+// of runs kept is limited by WithHistory. It returns nil if the history is disabled.
+func (t *Timer) History() []Run {
+	t.stats.mu.Lock()
+	defer t.stats.mu.Unlock()
+	return t.stats.history.list()
+}
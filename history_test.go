@@ -0,0 +1,95 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	h := history{size: 3}
+	require.Nil(t, h.list())
+
+	for i := 0; i < 5; i++ {
+		h.add(Run{Duration: time.Duration(i)})
+	}
+	runs := h.list()
+	require.Equal(t, 3, len(runs))
+	for i, run := range runs {
+		require.Equal(t, time.Duration(i+2), run.Duration)
+	}
+
+	h = history{size: 0}
+	h.add(Run{})
+	require.Nil(t, h.list())
+}
+
+func TestTimer_History(t *testing.T) {
+	tw := Default(WithHistory(2))
+	tw.Start()
+	defer tw.Stop()
+
+	var n int
+	timer := tw.ScheduleJob(context.Background(), Every(time.Hour), JobFunc(func(ctx context.Context) error {
+		n++
+		switch n {
+		case 1:
+			return nil
+		case 2:
+			return errors.New("failed")
+		default:
+			panic(fmt.Sprintf("run %d", n))
+		}
+	}))
+	defer timer.Close()
+	require.Nil(t, timer.History())
+
+	for i := 0; i < 3; i++ {
+		_ = timer.Trigger(context.Background())
+	}
+
+	runs := timer.History()
+	require.Equal(t, 2, len(runs))
+	require.Equal(t, "failed", runs[0].Err.Error())
+	require.False(t, runs[0].Panicked)
+	require.True(t, runs[1].Panicked)
+	require.True(t, runs[1].Triggered)
+	require.False(t, runs[1].Start.Before(runs[0].Start))
+
+	found, ok := tw.Lookup(timer.ID())
+	require.True(t, ok)
+	require.Equal(t, runs, found.History())
+
+	var infos []TimerInfo
+	tw.Range(func(info TimerInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	require.Equal(t, 1, len(infos))
+	require.Equal(t, runs, infos[0].History)
+}
+
+func TestTimer_History_Expired(t *testing.T) {
+	tw := Default(WithHistory(8))
+	tw.Start()
+	defer tw.Stop()
+
+	doneC := make(chan struct{})
+	expiration := time.Now().Add(-time.Second)
+	timer := tw.TimeFunc(context.Background(), expiration, func(ctx context.Context) error {
+		close(doneC)
+		return nil
+	})
+	<-doneC
+	time.Sleep(time.Millisecond * 10)
+
+	runs := timer.History()
+	require.Equal(t, 1, len(runs))
+	require.Equal(t, timeToMs(expiration), timeToMs(runs[0].Scheduled))
+	require.False(t, runs[0].Triggered)
+	require.NoError(t, runs[0].Err)
+}
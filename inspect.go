@@ -0,0 +1,194 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TimerInfo describes a pending timer.
+type TimerInfo struct {
+	ID         uint64
+	Name       string // See Timer.Name.
+	Key        string
+	Tags       []string
+	JobType    string // The job type name of named timer.
+	Spec       string // The spec of SpecSchedule.
+	Recurring  bool   // Whether the timer is created by ScheduleJob or ScheduleNamed.
+	Expiration time.Time
+	Level      int   // The level of wheel that holds the timer, 0 for the lowest, -1 if expired.
+	Held       bool  // Whether the timer has expired and is held by Pause.
+	Deferred   bool  // Whether the timer has expired and is deferred by the Quota of its tags.
+	History    []Run // See Timer.History.
+}
+
+// info returns the TimerInfo of t, it must be called with t's bucket (or the list
+// of held or deferred timers) locked. The level is the one passed to the fn of walk.
+func (t *Timer) info(level int, loc *time.Location) TimerInfo {
+	info := TimerInfo{
+		ID:         t.id,
+		Name:       t.Name(),
+		Key:        t.key,
+		Tags:       t.tags,
+		JobType:    t.jobType,
+		Spec:       "",
+		Recurring:  t.sh != nil,
+		Expiration: msToTime(t.expiration).In(loc),
+		Level:      level,
+		Held:       level == levelHeld,
+		Deferred:   level == levelDeferred,
+		History:    t.History(),
+	}
+	if level < 0 {
+		info.Level = -1
+	}
+	if sh, ok := t.sh.(SpecSchedule); ok {
+		info.Spec = sh.Spec()
+	}
+	return info
+}
+
+// The levels passed to the fn of walk for the expired timers that are not in buckets.
+const (
+	levelHeld     = -1 // Held by Pause.
+	levelDeferred = -2 // Deferred by the Quota of tags.
+)
+
+// walk calls fn for each timer in the buckets of tw and all its overflow wheels, then
+// the expired timers held by Pause or deferred by quotas with levelHeld and levelDeferred.
+// The fn is called with the bucket locked, so it must be fast and must not operate
+// the TimeWheel. Iteration stops if fn returns false.
+//
+// The timers that are being moved by bucket.flush at the moment may be missed.
+func (tw *TimeWheel) walk(fn func(level int, t *Timer) bool) {
+	level := 0
+	for w := tw; w != nil; w = (*TimeWheel)(atomic.LoadPointer(&w.overflow)) {
+		for _, b := range w.buckets {
+			if !b.walk(func(t *Timer) bool { return fn(level, t) }) {
+				return
+			}
+		}
+		level++
+	}
+
+	if !tw.walkHeld(func(t *Timer) bool { return fn(levelHeld, t) }) {
+		return
+	}
+	tw.walkDeferred(func(t *Timer) bool { return fn(levelDeferred, t) })
+}
+
+// walkHeld calls fn for each open timer held by Pause with pauseMu locked, stops if
+// fn returns false.
+func (tw *TimeWheel) walkHeld(fn func(t *Timer) bool) bool {
+	tw.pauseMu.Lock()
+	defer tw.pauseMu.Unlock()
+
+	for _, t := range tw.held {
+		if atomic.LoadInt32(&t.closed) == 0 && !fn(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkDeferred calls fn for each open timer deferred by quotas with quotaMu locked,
+// stops if fn returns false.
+func (tw *TimeWheel) walkDeferred(fn func(t *Timer) bool) bool {
+	tw.quotaMu.Lock()
+	defer tw.quotaMu.Unlock()
+
+	for _, q := range tw.quotas {
+		for _, r := range q.deferred {
+			// The timer of a triggered run is still in its bucket.
+			if r.triggered || atomic.LoadInt32(&r.t.closed) == 1 {
+				continue
+			}
+			if !fn(r.t) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Len returns the number of pending timers. A one-shot timer is pending until its
+// job is started, and a recurring timer is pending until it has no next execution.
+func (tw *TimeWheel) Len() int {
+	return int(atomic.LoadInt64(&tw.pending))
+}
+
+// Range calls fn for each pending timer across all levels of wheels, then the expired
+// timers that are held by Pause or deferred by quotas, and stops if fn returns false.
+//
+// Range locks only one bucket at a time and calls fn without lock, so it never
+// stalls the TimeWheel for long. Thus, the view is consistent within each bucket,
+// but the timers that are being moved between buckets at the moment may be missed.
+func (tw *TimeWheel) Range(fn func(info TimerInfo) bool) {
+	var infos []TimerInfo
+
+	level := 0
+	for w := tw; w != nil; w = (*TimeWheel)(atomic.LoadPointer(&w.overflow)) {
+		for _, b := range w.buckets {
+			infos = infos[:0]
+			b.walk(func(t *Timer) bool {
+				infos = append(infos, t.info(level, tw.location))
+				return true
+			})
+			for i := range infos {
+				if !fn(infos[i]) {
+					return
+				}
+			}
+		}
+		level++
+	}
+
+	infos = infos[:0]
+	tw.walkHeld(func(t *Timer) bool {
+		infos = append(infos, t.info(levelHeld, tw.location))
+		return true
+	})
+	tw.walkDeferred(func(t *Timer) bool {
+		infos = append(infos, t.info(levelDeferred, tw.location))
+		return true
+	})
+	for i := range infos {
+		if !fn(infos[i]) {
+			return
+		}
+	}
+}
+
+// NextExpiration returns the earliest expiration time of the pending timers.
+// It returns false if there is no pending timer.
+func (tw *TimeWheel) NextExpiration() (time.Time, bool) {
+	next := int64(-1)
+	tw.walk(func(level int, t *Timer) bool {
+		if next == -1 || t.expiration < next {
+			next = t.expiration
+		}
+		return true
+	})
+	if next == -1 {
+		return time.Time{}, false
+	}
+	return msToTime(next).In(tw.location), true
+}
+
+// Lookup returns the pending timer of id. It walks through all timers, thus it's slow
+// for a large number of timers.
+func (tw *TimeWheel) Lookup(id uint64) (*Timer, bool) {
+	var found *Timer
+	tw.walk(func(level int, t *Timer) bool {
+		if t.id == id {
+			found = t
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
@@ -0,0 +1,150 @@
+package timewheel
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_Range(t *testing.T) {
+	tw := New(time.Millisecond, 8)
+	fn := JobFunc(func(ctx context.Context) error { return nil })
+
+	_, ok := tw.NextExpiration()
+	require.False(t, ok)
+	require.Equal(t, 0, tw.Len())
+
+	now := time.Now()
+	t1 := tw.TimeFunc(context.Background(), now.Add(time.Millisecond*5), fn, WithTags("a"))
+	t2 := tw.Upsert(context.Background(), "k", now.Add(time.Second), fn)
+	t3 := tw.ScheduleJob(context.Background(), Every(time.Hour), fn)
+	require.Equal(t, 3, tw.Len())
+
+	var infos []TimerInfo
+	tw.Range(func(info TimerInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	require.Equal(t, 3, len(infos))
+
+	require.Equal(t, t1.ID(), infos[0].ID)
+	require.Equal(t, []string{"a"}, infos[0].Tags)
+	require.Equal(t, 0, infos[0].Level)
+	require.Equal(t, timeToMs(now.Add(time.Millisecond*5)), timeToMs(infos[0].Expiration))
+
+	require.Equal(t, t2.ID(), infos[1].ID)
+	require.Equal(t, "k", infos[1].Key)
+	require.Greater(t, infos[1].Level, 0)
+
+	require.Equal(t, t3.ID(), infos[2].ID)
+	require.True(t, infos[2].Recurring)
+	require.Equal(t, "@every 1h0m0s", infos[2].Spec)
+	require.Greater(t, infos[2].Level, infos[1].Level)
+
+	var n int
+	tw.Range(func(info TimerInfo) bool {
+		n++
+		return false
+	})
+	require.Equal(t, 1, n)
+
+	next, ok := tw.NextExpiration()
+	require.True(t, ok)
+	require.Equal(t, infos[0].Expiration, next)
+
+	t1.Close()
+	require.Equal(t, 2, tw.Len())
+	next, ok = tw.NextExpiration()
+	require.True(t, ok)
+	require.Equal(t, infos[1].Expiration, next)
+}
+
+func TestTimeWheel_Len(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	runC := make(chan struct{})
+	doneC := make(chan struct{})
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*5), func(ctx context.Context) error {
+		close(runC)
+		<-doneC
+		return nil
+	})
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond*5), JobFunc(func(ctx context.Context) error { return nil }))
+	require.Equal(t, 2, tw.Len())
+
+	// The running one-shot timer is no longer pending.
+	<-runC
+	require.Equal(t, 1, tw.Len())
+	close(doneC)
+
+	timer.Close()
+	require.Equal(t, 0, tw.Len())
+}
+
+func TestTimeWheel_Lookup(t *testing.T) {
+	tw := New(time.Millisecond, 8)
+	fn := JobFunc(func(ctx context.Context) error { return nil })
+
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(time.Second), fn)
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), fn)
+
+	got, ok := tw.Lookup(t2.ID())
+	require.True(t, ok)
+	require.Equal(t, t2, got)
+
+	t1.Close()
+	_, ok = tw.Lookup(t1.ID())
+	require.False(t, ok)
+}
+
+func TestTimeWheel_Range_Expired(t *testing.T) {
+	tw := New(time.Millisecond, 8, WithTagQuota("a", Quota{MaxRunning: 1}))
+	tw.Start()
+	defer tw.Stop()
+
+	blockC := make(chan struct{})
+	defer close(blockC)
+	fn := JobFunc(func(ctx context.Context) error {
+		<-blockC
+		return nil
+	})
+
+	// The second timer of tag "a" is deferred until the first one completes.
+	tw.TimeFunc(context.Background(), time.Now(), fn, WithTags("a"))
+	t2 := tw.TimeFunc(context.Background(), time.Now(), fn, WithTags("a"))
+	require.Eventually(t, func() bool { return tw.DeferredByTag("a") == 1 }, time.Second, time.Millisecond)
+
+	tw.Pause()
+	t3 := tw.TimeFunc(context.Background(), time.Now(), fn)
+	require.Eventually(t, func() bool { return tw.Held() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, 1, tw.Len())
+
+	infos := make(map[uint64]TimerInfo)
+	tw.Range(func(info TimerInfo) bool {
+		infos[info.ID] = info
+		return true
+	})
+	require.Equal(t, 2, len(infos))
+	require.True(t, infos[t2.ID()].Deferred)
+	require.Equal(t, -1, infos[t2.ID()].Level)
+	require.True(t, infos[t3.ID()].Held)
+	require.Equal(t, -1, infos[t3.ID()].Level)
+
+	got, ok := tw.Lookup(t3.ID())
+	require.True(t, ok)
+	require.Equal(t, t3, got)
+	_, ok = tw.Lookup(t2.ID())
+	require.True(t, ok)
+
+	// The closed timers are no longer visible.
+	t3.Close()
+	_, ok = tw.Lookup(t3.ID())
+	require.False(t, ok)
+	tw.Resume()
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"time"
+)
+
+// Upsert is like TimeFunc but the timer is identified by key, which is unique within
+// the TimeWheel. If there is a pending timer with the same key, it is closed and replaced
+// by the new one atomically, thus re-submitting the same key never duplicates timers.
+//
+// If the new timer exceeds the limit set by WithMaxTimers or the Quota of its tags,
+// it's rejected like TimeFunc, and the pending timer of key is kept. Replacing the
+// pending timer of key never exceeds them, since the count is unchanged. See
+// TryUpsert for the variant that returns the error.
+//
+// The key is released after the timer's job completed or the timer is closed.
+func (tw *TimeWheel) Upsert(ctx context.Context, key string, t time.Time, fn JobFunc, opts ...TimerOption) *Timer {
+	timer, _ := tw.upsert(ctx, key, t, fn, opts)
+	return timer
+}
+
+// upsert implements Upsert, it always returns the timer even if it's rejected by admit.
+func (tw *TimeWheel) upsert(ctx context.Context, key string, t time.Time, fn JobFunc, opts []TimerOption) (*Timer, error) {
+	timer := tw.newTimer(ctx, timeToMs(t), fn, opts)
+	timer.key = key
+	if err := tw.admit(timer, false); err != nil {
+		// The pending timer of key is kept.
+		return timer, err
+	}
+
+	tw.submitKeyed(timer)
+	return timer, nil
+}
+
+// submitKeyed submits the keyed timer t, the pending timer with the same key is
+// closed and replaced by t atomically.
+func (tw *TimeWheel) submitKeyed(t *Timer) {
+	if t.key == "" {
+		tw.submit(t)
+		return
+	}
+
+	tw.keysMu.Lock()
+	if tw.keys == nil {
+		tw.keys = make(map[string]*Timer)
+	}
+	old := tw.keys[t.key]
+	tw.keys[t.key] = t
+	tw.keysMu.Unlock()
+
+	if old != nil {
+		// The key has been taken by the new timer, thus unkey does nothing.
+		old.stop()
+		tw.cancel(old)
+	}
+	// Submit without keysMu held, the job may run synchronously and release the key.
+	tw.submit(t)
+}
+
+// Cancel closes the timer of key. It returns false if there is no such timer.
+func (tw *TimeWheel) Cancel(key string) bool {
+	tw.keysMu.Lock()
+	timer, ok := tw.keys[key]
+	delete(tw.keys, key)
+	tw.keysMu.Unlock()
+
+	if ok {
+		timer.Close()
+	}
+	return ok
+}
+
+// Get returns the timer of key.
+func (tw *TimeWheel) Get(key string) (*Timer, bool) {
+	tw.keysMu.Lock()
+	timer, ok := tw.keys[key]
+	tw.keysMu.Unlock()
+	return timer, ok
+}
+
+// unkey releases the key of t if it's still held by t.
+func (tw *TimeWheel) unkey(t *Timer) {
+	tw.keysMu.Lock()
+	if tw.keys[t.key] == t {
+		delete(tw.keys, t.key)
+	}
+	tw.keysMu.Unlock()
+}
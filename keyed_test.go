@@ -0,0 +1,125 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_Upsert(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	retC := make(chan int, 2)
+
+	t1 := tw.Upsert(context.Background(), "order-123", time.Now().Add(time.Millisecond*20), func(ctx context.Context) error {
+		retC <- 1
+		return nil
+	})
+	require.Equal(t, "order-123", t1.Key())
+
+	got, ok := tw.Get("order-123")
+	require.True(t, ok)
+	require.Equal(t, t1, got)
+
+	t2 := tw.Upsert(context.Background(), "order-123", time.Now().Add(time.Millisecond*40), func(ctx context.Context) error {
+		retC <- 2
+		return nil
+	})
+	got, ok = tw.Get("order-123")
+	require.True(t, ok)
+	require.Equal(t, t2, got)
+
+	// The replaced timer is closed.
+	require.NotNil(t, t1.ctxCancel.Err())
+	require.Equal(t, 2, <-retC)
+
+	require.Eventually(t, func() bool {
+		_, ok := tw.Get("order-123")
+		return !ok
+	}, time.Second, time.Millisecond*10)
+
+	select {
+	case v := <-retC:
+		require.Fail(t, "unexpected run", v)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestTimeWheel_Cancel(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	require.False(t, tw.Cancel("k"))
+
+	timer := tw.Upsert(context.Background(), "k", time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+	require.True(t, tw.Cancel("k"))
+	require.NotNil(t, timer.ctxCancel.Err())
+
+	_, ok := tw.Get("k")
+	require.False(t, ok)
+	require.False(t, tw.Cancel("k"))
+
+	// Closes the timer directly also releases the key.
+	timer = tw.Upsert(context.Background(), "k", time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+	timer.Close()
+	_, ok = tw.Get("k")
+	require.False(t, ok)
+}
+
+func TestTimeWheel_Upsert_Concurrent(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	var count int32
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tw.Upsert(context.Background(), "k", time.Now().Add(time.Millisecond*500), func(ctx context.Context) error {
+				atomic.AddInt32(&count, 1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		_, ok := tw.Get("k")
+		return !ok
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestTimeWheel_Upsert_Inline(t *testing.T) {
+	tw := New(time.Millisecond, 8, WithExecutor(InlineExecutor{}))
+
+	var runs int
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		// The expired timer runs its job synchronously and releases the key.
+		timer := tw.Upsert(context.Background(), "k", time.Now().Add(-time.Second), func(ctx context.Context) error {
+			runs++
+			return nil
+		})
+		<-timer.Done()
+	}()
+
+	select {
+	case <-doneC:
+	case <-time.After(time.Second):
+		t.Fatal("Upsert deadlocked")
+	}
+	require.Equal(t, 1, runs)
+	_, ok := tw.Get("k")
+	require.False(t, ok)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// laneItem is a job queued in a lane.
+type laneItem struct {
+	expiration int64  // The expiration of the timer when it's dispatched.
+	seq        uint64 // The dispatching order, for the items with same expiration.
+	fn         func()
+}
+
+// laneHeap implements heap.Interface, ordered by expiration then seq.
+type laneHeap []laneItem
+
+func (h laneHeap) Len() int { return len(h) }
+func (h laneHeap) Less(i, j int) bool {
+	if h[i].expiration != h[j].expiration {
+		return h[i].expiration < h[j].expiration
+	}
+	return h[i].seq < h[j].seq
+}
+func (h laneHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *laneHeap) Push(x interface{}) { *h = append(*h, x.(laneItem)) }
+func (h *laneHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = laneItem{}
+	*h = old[:n-1]
+	return item
+}
+
+// LaneExecutor is an Executor that serializes the jobs of timers with the same lane
+// set by WithLane, e.g. an account id. The jobs in a lane run one at a time in the
+// order of their expirations, and the different lanes run in parallel. The jobs of
+// timers without lane run in their own goroutines like the default Executor.
+//
+// Each lane has a goroutine while it has jobs queued or running.
+type LaneExecutor struct {
+	mu     sync.Mutex
+	lanes  map[string]*laneHeap
+	seq    uint64
+	queued int
+}
+
+// NewLaneExecutor creates a LaneExecutor.
+func NewLaneExecutor() *LaneExecutor {
+	return &LaneExecutor{
+		lanes: make(map[string]*laneHeap),
+	}
+}
+
+// Execute implements Executor. The job is ordered by t's expiration, thus t must not
+// be operated concurrently, the TimeWheel passes the expiration to it by executeAt.
+func (e *LaneExecutor) Execute(t *Timer, fn func()) {
+	e.executeAt(t, t.expiration, fn)
+}
+
+// executeAt implements scheduledExecutor, the job is ordered by the expiration it's
+// scheduled at.
+func (e *LaneExecutor) executeAt(t *Timer, expiration int64, fn func()) {
+	if t.lane == "" {
+		go fn()
+		return
+	}
+
+	e.mu.Lock()
+	e.seq++
+	e.queued++
+	lane, ok := e.lanes[t.lane]
+	if !ok {
+		lane = new(laneHeap)
+		e.lanes[t.lane] = lane
+	}
+	heap.Push(lane, laneItem{expiration: expiration, seq: e.seq, fn: fn})
+	e.mu.Unlock()
+
+	if !ok {
+		go e.drain(t.lane, lane)
+	}
+}
+
+// drain runs the jobs in the lane of key one by one, until it's empty.
+func (e *LaneExecutor) drain(key string, lane *laneHeap) {
+	for {
+		e.mu.Lock()
+		if lane.Len() == 0 {
+			delete(e.lanes, key)
+			e.mu.Unlock()
+			return
+		}
+		item := heap.Pop(lane).(laneItem)
+		e.queued--
+		e.mu.Unlock()
+
+		item.fn()
+	}
+}
+
+// Len returns the number of jobs waiting in lanes, it's reported in Stats.
+func (e *LaneExecutor) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.queued
+}
+
+// Lanes returns the number of lanes that have jobs queued or running.
+func (e *LaneExecutor) Lanes() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.lanes)
+}
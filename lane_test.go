@@ -0,0 +1,102 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLaneExecutor_Order(t *testing.T) {
+	e := NewLaneExecutor()
+
+	var mu sync.Mutex
+	var got []int64
+	startC := make(chan struct{})
+	blockC := make(chan struct{})
+	e.Execute(&Timer{lane: "a", expiration: 1}, func() {
+		close(startC)
+		<-blockC
+	})
+	<-startC
+
+	doneC := make(chan struct{})
+	for _, expiration := range []int64{30, 10, 20, 10} {
+		expiration := expiration
+		e.Execute(&Timer{lane: "a", expiration: expiration}, func() {
+			mu.Lock()
+			got = append(got, expiration)
+			if len(got) == 4 {
+				close(doneC)
+			}
+			mu.Unlock()
+		})
+	}
+	require.Equal(t, 4, e.Len())
+	require.Equal(t, 1, e.Lanes())
+
+	// The timers without lane run immediately.
+	freeC := make(chan struct{})
+	e.Execute(&Timer{}, func() { close(freeC) })
+	<-freeC
+
+	close(blockC)
+	<-doneC
+	require.Equal(t, []int64{10, 10, 20, 30}, got)
+	require.Eventually(t, func() bool { return e.Lanes() == 0 }, time.Second, time.Millisecond)
+	require.Equal(t, 0, e.Len())
+}
+
+func TestLaneExecutor_Serialized(t *testing.T) {
+	tw := Default(WithExecutor(NewLaneExecutor()))
+	tw.Start()
+	defer tw.Stop()
+
+	var running, maxRunning int32
+	lanes := map[string]*int32{"a": new(int32), "b": new(int32)}
+
+	var wg sync.WaitGroup
+	expiration := time.Now().Add(time.Millisecond * 10)
+	for i := 0; i < 20; i++ {
+		for key, n := range lanes {
+			n := n
+			wg.Add(1)
+			tw.TimeFunc(context.Background(), expiration, func(ctx context.Context) error {
+				defer wg.Done()
+				require.Equal(t, int32(1), atomic.AddInt32(n, 1), "lane runs concurrently")
+				if r := atomic.AddInt32(&running, 1); r > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, r)
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(n, -1)
+				return nil
+			}, WithLane(key))
+		}
+	}
+	wg.Wait()
+	require.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestLaneExecutor_Trigger(t *testing.T) {
+	tw := Default(WithExecutor(NewLaneExecutor()))
+	tw.Start()
+	defer tw.Stop()
+
+	var runs int32
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond), JobFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}), WithLane("a"))
+	defer timer.Close()
+
+	// Triggers while the recurring timer moves to its next cycles, it's checked
+	// by the race detector.
+	for i := 0; i < 50; i++ {
+		require.Nil(t, timer.Trigger(context.Background()))
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 60 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTooManyTimers is returned when a timer is created while the number of pending
+// timers reaches the limit set by WithMaxTimers.
+var ErrTooManyTimers = errors.New("timewheel: too many timers")
+
+// TryTimeFunc is like TimeFunc, but returns the error instead of a rejected timer if
+// the timer exceeds the limit of TimeWheel or the Quota of its tags.
+func (tw *TimeWheel) TryTimeFunc(ctx context.Context, t time.Time, fn JobFunc, opts ...TimerOption) (*Timer, error) {
+	timer, err := tw.timeFunc(ctx, t, fn, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// WaitTimeFunc is like TryTimeFunc, but waits for a free slot if the number of pending
+// timers reaches the limit set by WithMaxTimers. It returns ctx.Err() if ctx is done
+// before that. The Quota of tags is not waited for.
+func (tw *TimeWheel) WaitTimeFunc(ctx context.Context, t time.Time, fn JobFunc, opts ...TimerOption) (*Timer, error) {
+	timer, err := tw.timeFunc(ctx, t, fn, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// TryScheduleJob is like ScheduleJob, but returns the error instead of a rejected timer
+// if the timer exceeds the limit of TimeWheel or the Quota of its tags.
+func (tw *TimeWheel) TryScheduleJob(ctx context.Context, sh Schedule, job Job, opts ...TimerOption) (*Timer, error) {
+	timer, err := tw.scheduleJob(ctx, sh, job, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// WaitScheduleJob is like TryScheduleJob, but waits for a free slot like WaitTimeFunc.
+func (tw *TimeWheel) WaitScheduleJob(ctx context.Context, sh Schedule, job Job, opts ...TimerOption) (*Timer, error) {
+	timer, err := tw.scheduleJob(ctx, sh, job, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// TryUpsert is like Upsert, but returns the error instead of a rejected timer if the
+// timer exceeds the limit of TimeWheel or the Quota of its tags, the pending timer of
+// key is kept then.
+func (tw *TimeWheel) TryUpsert(ctx context.Context, key string, t time.Time, fn JobFunc, opts ...TimerOption) (*Timer, error) {
+	timer, err := tw.upsert(ctx, key, t, fn, opts)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// admit is like track, but rejects t if it exceeds the limit of tw or the Quota of its
+// tags, then t is closed and resolved with the error. If wait is true, it waits for
+// a free slot of the limit until the context of t is done.
+func (tw *TimeWheel) admit(t *Timer, wait bool) error {
+	if tw.maxTimers <= 0 && !tw.limited(t) {
+		tw.track(t)
+		return nil
+	}
+
+	for {
+		// Take the channel before the check, thus the slot freed after the check
+		// is not missed.
+		freeC := tw.freed()
+
+		err := tw.tryAdmit(t)
+		if err == nil {
+			return nil
+		}
+		if !wait || !errors.Is(err, ErrTooManyTimers) {
+			tw.reject(t, err)
+			return err
+		}
+
+		select {
+		case <-freeC:
+		case <-t.ctxCancel.Done():
+			err = t.ctxCancel.Err()
+			tw.reject(t, err)
+			return err
+		}
+	}
+}
+
+// tryAdmit tracks t if it does not exceed the limit of tw or the Quota of its tags.
+func (tw *TimeWheel) tryAdmit(t *Timer) error {
+	// The check and track must be atomic, otherwise the concurrent creations may
+	// all pass the check.
+	tw.admitMu.Lock()
+	defer tw.admitMu.Unlock()
+
+	// The timer that replaces the one of the same key does not count, since the
+	// replaced one is released right after, see submitKeyed.
+	var old *Timer
+	if t.key != "" {
+		old, _ = tw.Get(t.key)
+	}
+
+	if tw.maxTimers > 0 && atomic.LoadInt64(&tw.pending) >= tw.maxTimers &&
+		(old == nil || atomic.LoadInt32(&old.pending) == 0) {
+		return fmt.Errorf("%w: the limit is %d", ErrTooManyTimers, tw.maxTimers)
+	}
+	for _, tag := range t.tags {
+		if old != nil && atomic.LoadInt32(&old.pending) == 1 && hasTag(old, tag) {
+			continue
+		}
+		if q, ok := tw.quotas[tag]; ok && q.MaxPending > 0 && tw.pendingByTag(tag) >= q.MaxPending {
+			return fmt.Errorf("%w: tag %q has %d pending timers", ErrQuotaExceeded, tag, q.MaxPending)
+		}
+	}
+	tw.track(t)
+	return nil
+}
+
+// reject closes the untracked timer t and resolves it with err.
+func (tw *TimeWheel) reject(t *Timer, err error) {
+	t.closed = 1
+	t.finished = 1
+	t.cancelFunc()
+	t.resolve(err)
+	atomic.AddUint64(&tw.metrics.rejected, 1)
+	if tw.sink != nil {
+		tw.sink.IncRejected()
+	}
+	tw.logger.Debug("timewheel: timer rejected", "timer_id", t.id, "error", err)
+}
+
+// freed returns the channel that is closed when a pending timer is released.
+func (tw *TimeWheel) freed() <-chan struct{} {
+	tw.freeMu.Lock()
+	defer tw.freeMu.Unlock()
+	if tw.freeC == nil {
+		tw.freeC = make(chan struct{})
+	}
+	return tw.freeC
+}
+
+// free wakes up the invokers that are waiting for a free slot of the limit.
+func (tw *TimeWheel) free() {
+	tw.freeMu.Lock()
+	if tw.freeC != nil {
+		close(tw.freeC)
+		tw.freeC = nil
+	}
+	tw.freeMu.Unlock()
+}
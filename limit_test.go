@@ -0,0 +1,127 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_MaxTimers(t *testing.T) {
+	tw := Default(WithMaxTimers(2))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	job := func(ctx context.Context) error { return nil }
+
+	t1, err := tw.TryTimeFunc(ctx, at, job)
+	require.Nil(t, err)
+	t2, err := tw.TryScheduleJob(ctx, Every(time.Hour), JobFunc(job))
+	require.Nil(t, err)
+
+	t3, err := tw.TryTimeFunc(ctx, at, job)
+	require.True(t, errors.Is(err, ErrTooManyTimers))
+	require.Nil(t, t3)
+	_, err = tw.TryScheduleJob(ctx, Every(time.Hour), JobFunc(job))
+	require.True(t, errors.Is(err, ErrTooManyTimers))
+
+	// TimeFunc returns the rejected timer.
+	t4 := tw.TimeFunc(ctx, at, job)
+	<-t4.Done()
+	require.True(t, errors.Is(t4.Err(), ErrTooManyTimers))
+
+	stats := tw.Stats()
+	require.Equal(t, 2, stats.Pending)
+	require.Equal(t, 2, stats.MaxTimers)
+	require.Equal(t, uint64(3), stats.Rejected)
+	require.Equal(t, uint64(2), stats.Added)
+
+	// The slot is freed after the timer is closed.
+	t1.Close()
+	t5, err := tw.TryTimeFunc(ctx, at, job)
+	require.Nil(t, err)
+
+	t2.Close()
+	t5.Close()
+	require.Equal(t, 0, tw.Len())
+}
+
+func TestTimeWheel_WaitTimeFunc(t *testing.T) {
+	tw := Default(WithMaxTimers(1))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	job := func(ctx context.Context) error { return nil }
+
+	t1, err := tw.WaitTimeFunc(ctx, time.Now().Add(20*time.Millisecond), job)
+	require.Nil(t, err)
+
+	// Waits until t1 expires.
+	t2, err := tw.WaitTimeFunc(ctx, time.Now().Add(time.Hour), job)
+	require.Nil(t, err)
+	<-t1.Done()
+	require.Equal(t, 1, tw.Len())
+
+	// Gives up when ctx is done.
+	ctx1, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	t3, err := tw.WaitScheduleJob(ctx1, Every(time.Hour), JobFunc(job))
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Nil(t, t3)
+
+	// Wakes up when t2 is closed.
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		t4, err := tw.WaitTimeFunc(ctx, time.Now().Add(time.Hour), job)
+		require.Nil(t, err)
+		t4.Close()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	t2.Close()
+	<-doneC
+	require.Equal(t, 0, tw.Len())
+}
+
+func TestTimeWheel_MaxTimers_Upsert(t *testing.T) {
+	tw := Default(WithMaxTimers(1))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	job := func(ctx context.Context) error { return nil }
+
+	t1 := tw.Upsert(ctx, "k", at, job)
+	require.Nil(t, t1.Err())
+
+	// Replacing the timer of the same key keeps the count.
+	t2 := tw.Upsert(ctx, "k", at, job)
+	require.Nil(t, t2.Err())
+	require.Equal(t, ErrTimerClosed, t1.Err())
+	require.Equal(t, 1, tw.Len())
+
+	// The other keys are still limited.
+	t3 := tw.Upsert(ctx, "k2", at, job)
+	require.True(t, errors.Is(t3.Err(), ErrTooManyTimers))
+	got, ok := tw.Get("k")
+	require.True(t, ok)
+	require.Equal(t, t2, got)
+
+	t4, err := tw.TryUpsert(ctx, "k2", at, job)
+	require.True(t, errors.Is(err, ErrTooManyTimers))
+	require.Nil(t, t4)
+	_, ok = tw.Get("k2")
+	require.False(t, ok)
+
+	t5, err := tw.TryUpsert(ctx, "k", at, job)
+	require.Nil(t, err)
+	require.Equal(t, ErrTimerClosed, t2.Err())
+	require.Equal(t, uint64(2), tw.Stats().Rejected)
+	t5.Close()
+}
@@ -0,0 +1,87 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *testLogger) log(level, msg string) {
+	l.mu.Lock()
+	l.logs = append(l.logs, level+" "+msg)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) has(log string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, v := range l.logs {
+		if v == log {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg) }
+func (l *testLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg) }
+
+func TestWithLogger(t *testing.T) {
+	logger := new(testLogger)
+	tw := New(time.Millisecond, 4, WithLogger(logger))
+	tw.Start()
+	require.True(t, logger.has("INFO timewheel: started"))
+
+	tw.TimeFunc(context.Background(), time.Now(), func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	tw.TimeFunc(context.Background(), time.Now(), func(ctx context.Context) error {
+		panic("boom")
+	})
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+
+	var n int
+	timer := tw.ScheduleJob(context.Background(), ScheduleFunc(func(prev time.Time) time.Time {
+		n++
+		if n > 2 {
+			return time.Time{}
+		}
+		return prev
+	}), JobFunc(func(ctx context.Context) error { return nil }))
+
+	require.Eventually(t, func() bool {
+		return logger.has("ERROR timewheel: job failed") &&
+			logger.has("ERROR timewheel: job panic") &&
+			logger.has("WARN timewheel: schedule returns non-advancing time")
+	}, time.Second, time.Millisecond*10)
+	require.True(t, logger.has("DEBUG timewheel: overflow wheel created"))
+
+	timer.Close()
+	tw.Stop()
+	require.True(t, logger.has("INFO timewheel: stopped"))
+}
+
+func TestTimeWheel_call_Panic(t *testing.T) {
+	tw := Default()
+	timer := tw.newTimer(context.Background(), 0, func(ctx context.Context) error {
+		panic("boom")
+	}, nil)
+
+	err := tw.call(context.Background(), timer.jobFunc)
+	pe, ok := err.(*PanicError)
+	require.True(t, ok)
+	require.Equal(t, "boom", pe.Value)
+	require.NotEmpty(t, pe.Stack)
+	require.Equal(t, "timewheel: job panic: boom", err.Error())
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package metrics exports the statistics of TimeWheel in the Prometheus text
+// exposition format and by the expvar, without any extra dependency.
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/yu31/timewheel-go"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler returns an http.Handler that serves the metrics of tw in
+// the Prometheus text exposition format.
+func Handler(tw *timewheel.TimeWheel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = WriteText(w, tw.Stats())
+	})
+}
+
+// WriteText writes the stats to w in the Prometheus text exposition format,
+// all metric names are prefixed by "timewheel_".
+func WriteText(w io.Writer, stats timewheel.Stats) error {
+	bw := bufio.NewWriter(w)
+
+	writeMetric(bw, "timewheel_timers_added_total", "counter", "The total number of added timers.", float64(stats.Added))
+	writeMetric(bw, "timewheel_timers_fired_total", "counter", "The total number of fired timers.", float64(stats.Fired))
+	writeMetric(bw, "timewheel_timers_cancelled_total", "counter", "The total number of cancelled pending timers.", float64(stats.Cancelled))
+	writeMetric(bw, "timewheel_timers_rejected_total", "counter", "The total number of timers rejected by the limit or quotas.", float64(stats.Rejected))
+	writeMetric(bw, "timewheel_timers_pending", "gauge", "The number of pending timers.", float64(stats.Pending))
+	writeMetric(bw, "timewheel_timers_max", "gauge", "The limit of pending timers, 0 means no limit.", float64(stats.MaxTimers))
+
+	writeHeader(bw, "timewheel_level_timers", "gauge", "The number of timers in the buckets of each level of wheels.")
+	for level, n := range stats.PendingByLevel {
+		fmt.Fprintf(bw, "timewheel_level_timers{level=\"%d\"} %d\n", level, n)
+	}
+
+	writeMetric(bw, "timewheel_overflow_depth", "gauge", "The number of overflow wheels.", float64(stats.OverflowDepth))
+	writeMetric(bw, "timewheel_jobs_running", "gauge", "The number of running jobs.", float64(stats.Running))
+	writeMetric(bw, "timewheel_executor_queue_length", "gauge", "The queue length of the executor.", float64(stats.ExecutorQueue))
+
+	h := stats.Lateness
+	writeHeader(bw, "timewheel_lateness_seconds", "histogram", "The actual dispatch time minus the expiration of timers.")
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(bw, "timewheel_lateness_seconds_bucket{le=\"%s\"} %d\n", formatFloat(bound.Seconds()), cumulative)
+	}
+	// The +Inf bucket and count are derived from the buckets instead of h.Count,
+	// which is loaded separately, thus the buckets stay monotonic.
+	if len(h.Counts) > len(h.Bounds) {
+		cumulative += h.Counts[len(h.Bounds)]
+	}
+	fmt.Fprintf(bw, "timewheel_lateness_seconds_bucket{le=\"+Inf\"} %d\n", cumulative)
+	fmt.Fprintf(bw, "timewheel_lateness_seconds_sum %s\n", formatFloat(h.Sum.Seconds()))
+	fmt.Fprintf(bw, "timewheel_lateness_seconds_count %d\n", cumulative)
+
+	return bw.Flush()
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeMetric(w io.Writer, name, typ, help string, value float64) {
+	writeHeader(w, name, typ, help)
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// PublishExpvar publishes the stats of tw as an expvar variable with name.
+// Like expvar.Publish, it panics if the name is already registered.
+func PublishExpvar(name string, tw *timewheel.TimeWheel) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return tw.Stats()
+	}))
+}
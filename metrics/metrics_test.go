@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yu31/timewheel-go"
+)
+
+func TestWriteText(t *testing.T) {
+	stats := timewheel.Stats{
+		Added:          3,
+		Fired:          2,
+		Cancelled:      1,
+		Rejected:       4,
+		Pending:        1,
+		MaxTimers:      10,
+		PendingByLevel: []int{0, 1},
+		OverflowDepth:  1,
+		Running:        1,
+		ExecutorQueue:  0,
+		Lateness: timewheel.Histogram{
+			Bounds: []time.Duration{time.Millisecond, time.Second},
+			Counts: []uint64{1, 0, 1},
+			Count:  2,
+			Sum:    time.Second * 2,
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.Nil(t, WriteText(buf, stats))
+	text := buf.String()
+
+	for _, line := range []string{
+		"# TYPE timewheel_timers_added_total counter",
+		"timewheel_timers_added_total 3",
+		"timewheel_timers_fired_total 2",
+		"timewheel_timers_cancelled_total 1",
+		"timewheel_timers_rejected_total 4",
+		"timewheel_timers_pending 1",
+		"timewheel_timers_max 10",
+		`timewheel_level_timers{level="0"} 0`,
+		`timewheel_level_timers{level="1"} 1`,
+		"timewheel_overflow_depth 1",
+		"timewheel_jobs_running 1",
+		"timewheel_executor_queue_length 0",
+		"# TYPE timewheel_lateness_seconds histogram",
+		`timewheel_lateness_seconds_bucket{le="0.001"} 1`,
+		`timewheel_lateness_seconds_bucket{le="1"} 1`,
+		`timewheel_lateness_seconds_bucket{le="+Inf"} 2`,
+		"timewheel_lateness_seconds_sum 2",
+		"timewheel_lateness_seconds_count 2",
+	} {
+		require.Contains(t, text, line+"\n")
+	}
+
+	// The +Inf bucket never goes below the last bucket, even if the Count is loaded
+	// before the concurrent updates of buckets.
+	stats.Lateness.Counts = []uint64{1, 1, 1}
+	buf.Reset()
+	require.Nil(t, WriteText(buf, stats))
+	require.Contains(t, buf.String(), `timewheel_lateness_seconds_bucket{le="+Inf"} 3`+"\n")
+	require.Contains(t, buf.String(), "timewheel_lateness_seconds_count 3\n")
+}
+
+func TestHandler(t *testing.T) {
+	tw := timewheel.Default()
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	Handler(tw).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, 200, rec.Code)
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	require.True(t, strings.Contains(rec.Body.String(), "timewheel_timers_pending 1\n"))
+}
+
+func TestPublishExpvar(t *testing.T) {
+	tw := timewheel.Default()
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+
+	// The expvar can't be unpublished, thus use a unique name for each run.
+	name := fmt.Sprintf("timewheel_test_%d", time.Now().UnixNano())
+	PublishExpvar(name, tw)
+
+	var stats timewheel.Stats
+	require.Nil(t, json.Unmarshal([]byte(expvar.Get(name).String()), &stats))
+	require.Equal(t, uint64(1), stats.Added)
+
+	require.Panics(t, func() {
+		PublishExpvar(name, tw)
+	})
+}
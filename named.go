@@ -0,0 +1,98 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrScheduleNotSerializable is returned by ScheduleNamed if the timer needs to be
+// persisted but the Schedule is not a SpecSchedule, and by Snapshot if such a timer
+// is pending.
+var ErrScheduleNotSerializable = errors.New("timewheel: schedule is not serializable")
+
+// RegisterJob registers the factory of the job type name into the TimeWheel's Registry.
+// It's a shortcut for tw.Registry().Register(name, factory).
+func (tw *TimeWheel) RegisterJob(name string, factory JobFactory) {
+	tw.registry.Register(name, factory)
+}
+
+// Registry returns the Registry of the TimeWheel.
+func (tw *TimeWheel) Registry() *Registry {
+	return tw.registry
+}
+
+// TimeNamed waits until the appointed time and then calls the job created by the
+// registered job type name with payload in its own goroutine.
+//
+// If a Store is set, the timer is saved before it is inserted into the TimeWheel,
+// and be deleted after its job completed or it is closed.
+func (tw *TimeWheel) TimeNamed(ctx context.Context, t time.Time, name string, payload []byte, opts ...TimerOption) (*Timer, error) {
+	job, err := tw.registry.New(name, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := tw.newTimer(ctx, timeToMs(t), job.Run, opts)
+	timer.jobType = name
+	timer.payload = payload
+
+	if err := tw.admit(timer, false); err != nil {
+		return nil, err
+	}
+	if err := tw.persist(timer); err != nil {
+		timer.Close()
+		return nil, err
+	}
+
+	tw.submitKeyed(timer)
+	return timer, nil
+}
+
+// ScheduleNamed calls the job created by the registered job type name with payload
+// according to the execution plan scheduled by sh.Next, see ScheduleJob.
+//
+// If a Store is set, sh must be a SpecSchedule. The timer is saved before it is
+// inserted into the TimeWheel, updated each cycle, and deleted when there is no
+// next execution or it is closed.
+func (tw *TimeWheel) ScheduleNamed(ctx context.Context, sh Schedule, name string, payload []byte, opts ...TimerOption) (*Timer, error) {
+	job, err := tw.registry.New(name, payload)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sh.(SpecSchedule); !ok && tw.store != nil {
+		return nil, ErrScheduleNotSerializable
+	}
+
+	timer := tw.newTimer(ctx, 0, nil, opts)
+	timer.sh = sh
+	timer.jobType = name
+	timer.payload = payload
+
+	next1 := sh.Next(time.Now().In(tw.location))
+	if next1.IsZero() {
+		// No time is scheduled, return empty timer.
+		timer.finished = 1
+		timer.resolve(nil)
+		return timer, nil
+	}
+
+	timer.expiration = timeToMs(next1)
+	timer.jobFunc = job.Run
+
+	if err := tw.admit(timer, false); err != nil {
+		return nil, err
+	}
+	if err := tw.persist(timer); err != nil {
+		timer.Close()
+		return nil, err
+	}
+
+	tw.submitKeyed(timer)
+	return timer, nil
+}
@@ -0,0 +1,87 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_TimeNamed(t *testing.T) {
+	store := newTestFileStore(t)
+
+	tw := Default(WithStore(store))
+	tw.Start()
+	defer tw.Stop()
+
+	_, err := tw.TimeNamed(context.Background(), time.Now(), "echo", nil)
+	require.True(t, errors.Is(err, ErrJobTypeNotRegistered))
+
+	retC := make(chan string)
+	tw.RegisterJob("echo", func(payload []byte) (Job, error) {
+		if len(payload) == 0 {
+			return nil, errors.New("empty payload")
+		}
+		return JobFunc(func(ctx context.Context) error {
+			retC <- string(payload)
+			return nil
+		}), nil
+	})
+
+	_, err = tw.TimeNamed(context.Background(), time.Now(), "echo", nil)
+	require.NotNil(t, err)
+
+	timer, err := tw.TimeNamed(context.Background(), time.Now().Add(time.Millisecond*10), "echo", []byte("hello"))
+	require.Nil(t, err)
+
+	records, err := store.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, []*Record{timer.record()}, records)
+
+	require.Equal(t, "hello", <-retC)
+
+	// The record is deleted after the job completed.
+	require.Eventually(t, func() bool {
+		records, err := store.LoadAll()
+		require.Nil(t, err)
+		return len(records) == 0
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestTimeWheel_ScheduleNamed(t *testing.T) {
+	store := newTestFileStore(t)
+
+	tw := Default(WithStore(store))
+	tw.Start()
+	defer tw.Stop()
+
+	retC := make(chan string)
+	tw.Registry().RegisterFunc("echo", func(ctx context.Context, payload []byte) error {
+		retC <- string(payload)
+		return nil
+	})
+
+	_, err := tw.ScheduleNamed(context.Background(), ScheduleFunc(func(t time.Time) time.Time {
+		return t.Add(time.Millisecond)
+	}), "echo", nil)
+	require.Equal(t, ErrScheduleNotSerializable, err)
+
+	timer, err := tw.ScheduleNamed(context.Background(), Every(time.Millisecond*10), "echo", []byte("tick"))
+	require.Nil(t, err)
+
+	records, err := store.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "@every 10ms", records[0].Spec)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, "tick", <-retC)
+	}
+	timer.Close()
+
+	records, err = store.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import "time"
+
+// Observer receives the lifecycle events of timers, it's used to layer tracing,
+// auditing and metrics on the TimeWheel. The methods are called synchronously,
+// so they must be fast, safe for concurrent use and must not panic.
+//
+// Embeds the NopObserver to implement only part of the methods.
+type Observer interface {
+	// OnAdd is called when a timer is added to the TimeWheel.
+	OnAdd(t *Timer)
+	// OnCancel is called when a pending timer is closed.
+	OnCancel(t *Timer)
+	// OnExpire is called when a timer expires and its job is about to be dispatched,
+	// lateness is the actual dispatch time minus the expiration.
+	OnExpire(t *Timer, lateness time.Duration)
+	// OnJobStart is called in the job's goroutine before the job starts.
+	OnJobStart(t *Timer)
+	// OnJobEnd is called in the job's goroutine after the job returns.
+	OnJobEnd(t *Timer, err error, elapsed time.Duration)
+	// OnOverflowCreated is called when an overflow wheel of level is created,
+	// span is the time span of the new wheel.
+	OnOverflowCreated(level int, span time.Duration)
+}
+
+// NopObserver is an Observer that does nothing.
+type NopObserver struct{}
+
+func (NopObserver) OnAdd(t *Timer)                                      {}
+func (NopObserver) OnCancel(t *Timer)                                   {}
+func (NopObserver) OnExpire(t *Timer, lateness time.Duration)           {}
+func (NopObserver) OnJobStart(t *Timer)                                 {}
+func (NopObserver) OnJobEnd(t *Timer, err error, elapsed time.Duration) {}
+func (NopObserver) OnOverflowCreated(level int, span time.Duration)     {}
@@ -0,0 +1,81 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testObserver struct {
+	NopObserver
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *testObserver) record(format string, args ...interface{}) {
+	o.mu.Lock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+	o.mu.Unlock()
+}
+
+func (o *testObserver) get() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.events...)
+}
+
+func (o *testObserver) OnAdd(t *Timer)    { o.record("add %d", t.ID()) }
+func (o *testObserver) OnCancel(t *Timer) { o.record("cancel %d", t.ID()) }
+func (o *testObserver) OnExpire(t *Timer, lateness time.Duration) {
+	o.record("expire %d", t.ID())
+}
+func (o *testObserver) OnJobStart(t *Timer) { o.record("start %d", t.ID()) }
+func (o *testObserver) OnJobEnd(t *Timer, err error, elapsed time.Duration) {
+	o.record("end %d %v", t.ID(), err)
+}
+func (o *testObserver) OnOverflowCreated(level int, span time.Duration) {
+	o.record("overflow %d %s", level, span)
+}
+
+func TestWithObserver(t *testing.T) {
+	o := new(testObserver)
+	tw := New(time.Millisecond, 4, WithObserver(o))
+	tw.Start()
+	defer tw.Stop()
+
+	doneC := make(chan struct{})
+	t1 := tw.TimeFunc(context.Background(), time.Now(), func(ctx context.Context) error {
+		defer close(doneC)
+		return errors.New("failed")
+	})
+	<-doneC
+	require.Eventually(t, func() bool {
+		return len(o.get()) == 4
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, []string{
+		fmt.Sprintf("add %d", t1.ID()),
+		fmt.Sprintf("expire %d", t1.ID()),
+		fmt.Sprintf("start %d", t1.ID()),
+		fmt.Sprintf("end %d failed", t1.ID()),
+	}, o.get())
+
+	o.mu.Lock()
+	o.events = nil
+	o.mu.Unlock()
+
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*100), func(ctx context.Context) error { return nil })
+	t2.Close()
+	require.Equal(t, []string{
+		fmt.Sprintf("add %d", t2.ID()),
+		"overflow 1 16ms",
+		"overflow 2 64ms",
+		"overflow 3 256ms",
+		fmt.Sprintf("cancel %d", t2.ID()),
+	}, o.get())
+}
@@ -11,3 +11,209 @@ func WithTimezone(loc *time.Location) Option {
 		tw.location = loc
 	}
 }
+
+// WithStore sets the Store for persisting the timers created by registered job
+// types, thus they can be recovered after restart.
+func WithStore(store Store) Option {
+	return func(tw *TimeWheel) {
+		tw.store = store
+	}
+}
+
+// WithMisfirePolicy sets the policy for the recovered timers that have expired
+// while the TimeWheel was not running. The default is MisfireFireNow.
+func WithMisfirePolicy(policy MisfirePolicy) Option {
+	return func(tw *TimeWheel) {
+		tw.misfire = policy
+	}
+}
+
+// WithRegistry sets the Registry of job types, it allows multiple TimeWheel share
+// the same job types.
+func WithRegistry(registry *Registry) Option {
+	return func(tw *TimeWheel) {
+		tw.registry = registry
+	}
+}
+
+// WithExecutor sets the Executor for running the jobs of expired timers.
+// The default executor runs each job in its own goroutine.
+func WithExecutor(executor Executor) Option {
+	return func(tw *TimeWheel) {
+		tw.executor = executor
+	}
+}
+
+// WithMetricsSink sets the sink that the TimeWheel reports its metrics to.
+func WithMetricsSink(sink MetricsSink) Option {
+	return func(tw *TimeWheel) {
+		tw.sink = sink
+	}
+}
+
+// WithObserver adds the Observer of timer lifecycle events, it can be used
+// multiple times to add multiple observers.
+func WithObserver(o Observer) Option {
+	return func(tw *TimeWheel) {
+		tw.observers = append(tw.observers, o)
+	}
+}
+
+// WithLogger sets the Logger, e.g. a *slog.Logger. The TimeWheel logs the
+// lifecycle events at Info and Debug level, the misfires and non-advancing
+// schedules at Warn level, and the job failures and panics at Error level.
+func WithLogger(logger Logger) Option {
+	return func(tw *TimeWheel) {
+		tw.logger = logger
+	}
+}
+
+// WithTracer sets the Tracer that wraps each run of jobs with a span.
+func WithTracer(tracer Tracer) Option {
+	return func(tw *TimeWheel) {
+		tw.tracer = tracer
+	}
+}
+
+// WithHistory keeps the last n runs of job for each timer, see Timer.History.
+// It's disabled by default, since each run record costs memory of every timer.
+func WithHistory(n int) Option {
+	return func(tw *TimeWheel) {
+		tw.historySize = n
+	}
+}
+
+// WithJobTimeout sets the default timeout of each run of jobs. The job's context is
+// cancelled after the timeout, and the run that exceeds it is reported to the Logger
+// and Observers with a *TimeoutError. It's disabled by default.
+func WithJobTimeout(d time.Duration) Option {
+	return func(tw *TimeWheel) {
+		tw.jobTimeout = d
+	}
+}
+
+// WithHangGrace enables reporting the jobs that are still running after their timeout
+// plus the grace period, i.e. ignore the cancellation, to the Logger and HangObservers.
+func WithHangGrace(grace time.Duration) Option {
+	return func(tw *TimeWheel) {
+		tw.hangGrace = grace
+	}
+}
+
+// WithManualAdvance enables the manual mode, in which the TimeWheel does not run its
+// own goroutine, the invoker must drive it by calling Advance, e.g. in a custom event
+// loop. See NextDeadline for when to call Advance next.
+func WithManualAdvance() Option {
+	return func(tw *TimeWheel) {
+		tw.manual = new(bucketQueue)
+	}
+}
+
+// WithBatchHandler enables the batch mode, in which the timers expired in the same
+// bucket are passed to handler as a batch in one goroutine, instead of running their
+// jobs by the Executor one by one. The jobs of timers are not called in batch mode,
+// the handler takes charge of them, e.g. by the timer's Key or ID.
+//
+// The recurring timers are resubmitted to their next cycle before the handler is
+// called, and the others leave the TimeWheel after the handler returns.
+func WithBatchHandler(handler func(expired []*Timer)) Option {
+	return func(tw *TimeWheel) {
+		tw.batch = handler
+	}
+}
+
+// WithMaxTimers limits the number of pending timers to n, which bounds the memory
+// used by the TimeWheel. The timers created beyond it are rejected with
+// ErrTooManyTimers, or wait for a free slot by WaitTimeFunc and WaitScheduleJob.
+// The recovered and restored timers are not limited.
+func WithMaxTimers(n int) Option {
+	return func(tw *TimeWheel) {
+		tw.maxTimers = int64(n)
+	}
+}
+
+// WithTagQuota limits the timers with tag by q, e.g. to keep one tenant from
+// monopolizing the TimeWheel. It can be used multiple times for different tags.
+//
+// The timers with tag created beyond q.MaxPending are rejected, and the expired
+// timers beyond q.MaxRunning are deferred, see Quota.
+func WithTagQuota(tag string, q Quota) Option {
+	return func(tw *TimeWheel) {
+		if tw.quotas == nil {
+			tw.quotas = make(map[string]*quotaState)
+		}
+		tw.quotas[tag] = &quotaState{Quota: q}
+	}
+}
+
+// TimerOption represents a modification to the default behavior of a Timer.
+type TimerOption func(t *Timer)
+
+// WithTags sets the tags of the timer, the timers can be counted and canceled by tag.
+func WithTags(tags ...string) TimerOption {
+	return func(t *Timer) {
+		t.tags = append(t.tags, tags...)
+	}
+}
+
+// WithGroup adds the timer to the group g, the timer is closed when g is closed.
+func WithGroup(g *Group) TimerOption {
+	return func(t *Timer) {
+		t.group = g
+	}
+}
+
+// WithKey sets the key of the timer, the pending timer with the same key is closed
+// and replaced by it, see Upsert. The key of named timers is kept by Snapshot and Store.
+func WithKey(key string) TimerOption {
+	return func(t *Timer) {
+		t.key = key
+	}
+}
+
+// WithName sets the name of the timer, it's used as the span name by Tracer.
+func WithName(name string) TimerOption {
+	return func(t *Timer) {
+		t.name = name
+	}
+}
+
+// WithOverlapPolicy sets the policy for a run of the job that starts while the
+// previous one is still running, default is OverlapAllow.
+func WithOverlapPolicy(policy OverlapPolicy) TimerOption {
+	return func(t *Timer) {
+		t.overlap = policy
+	}
+}
+
+// WithTimeout sets the timeout of each run of the timer's job, it overrides the one
+// set by WithJobTimeout. A negative d disables the timeout for the timer.
+func WithTimeout(d time.Duration) TimerOption {
+	return func(t *Timer) {
+		t.timeout = d
+	}
+}
+
+// WithRetry retries the failed runs of the timer's job according to p. The runs
+// started by Timer.Trigger are not retried.
+func WithRetry(p RetryPolicy) TimerOption {
+	return func(t *Timer) {
+		t.retry = &p
+	}
+}
+
+// WithLane sets the lane of the timer, the jobs of timers in the same lane never run
+// concurrently and run in the order of expirations with the LaneExecutor.
+func WithLane(key string) TimerOption {
+	return func(t *Timer) {
+		t.lane = key
+	}
+}
+
+// WithPriority sets the priority of the timer, default is 0. The jobs with higher
+// priority run first when the PriorityExecutor is saturated.
+func WithPriority(priority int) TimerOption {
+	return func(t *Timer) {
+		t.priority = priority
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrOverlap is returned by Timer.Trigger when the run is skipped by OverlapSkip.
+var ErrOverlap = errors.New("timewheel: job is already running")
+
+// OverlapPolicy decides what to do with a run of the job that starts while the
+// previous one is still running, e.g. a recurring job runs longer than its interval
+// or the job is triggered manually.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow runs the jobs concurrently.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip skips the new run, it's counted as Skipped in TimerStats.
+	OverlapSkip
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapAllow:
+		return "allow"
+	case OverlapSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// acquire marks a run of t's job is started, returns false if it's not allowed
+// by the overlap policy.
+func (t *Timer) acquire() bool {
+	if t.overlap == OverlapSkip {
+		return atomic.CompareAndSwapInt32(&t.running, 0, 1)
+	}
+	atomic.AddInt32(&t.running, 1)
+	return true
+}
+
+// release marks a run of t's job is completed.
+func (t *Timer) release() {
+	atomic.AddInt32(&t.running, -1)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import "sync/atomic"
+
+// Pause stops dispatching the jobs of expired timers, the clock keeps moving and the
+// expired timers are held until Resume. The running jobs are not affected.
+func (tw *TimeWheel) Pause() {
+	tw.pauseMu.Lock()
+	atomic.StoreInt32(&tw.paused, 1)
+	tw.pauseMu.Unlock()
+
+	tw.logger.Info("timewheel: paused")
+}
+
+// Resume dispatches the timers held by Pause, and restores the dispatching.
+//
+// Notice: the held recurring timers calculate their next execution time from
+// the missed one, thus they may be fired multiple times in a row to catch up.
+func (tw *TimeWheel) Resume() {
+	tw.pauseMu.Lock()
+	atomic.StoreInt32(&tw.paused, 0)
+	held := tw.held
+	tw.held = nil
+	tw.pauseMu.Unlock()
+
+	tw.logger.Info("timewheel: resumed", "held", len(held))
+	if tw.batch != nil && len(held) != 0 {
+		tw.dispatchBatch(held)
+		return
+	}
+	for _, t := range held {
+		tw.dispatch(t)
+	}
+}
+
+// Paused reports whether the TimeWheel is paused.
+func (tw *TimeWheel) Paused() bool {
+	return atomic.LoadInt32(&tw.paused) == 1
+}
+
+// Held returns the number of expired timers held by Pause.
+func (tw *TimeWheel) Held() int {
+	tw.pauseMu.Lock()
+	n := len(tw.held)
+	tw.pauseMu.Unlock()
+	return n
+}
+
+// hold holds t if the TimeWheel is paused, returns false if not paused.
+func (tw *TimeWheel) hold(t *Timer) bool {
+	if atomic.LoadInt32(&tw.paused) == 0 {
+		return false
+	}
+
+	tw.pauseMu.Lock()
+	defer tw.pauseMu.Unlock()
+
+	// Check again, Resume may have been called.
+	if tw.paused == 0 {
+		return false
+	}
+	tw.held = append(tw.held, t)
+	return true
+}
@@ -0,0 +1,47 @@
+package timewheel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_Pause(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	var count int32
+	fn := func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}
+
+	tw.Pause()
+	require.True(t, tw.Paused())
+
+	tw.TimeFunc(context.Background(), time.Now(), fn)
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*5), fn)
+	closed := tw.TimeFunc(context.Background(), time.Now(), fn)
+
+	require.Eventually(t, func() bool {
+		return tw.Held() == 3
+	}, time.Second, time.Millisecond*5)
+	require.Equal(t, int32(0), atomic.LoadInt32(&count))
+	require.Equal(t, 3, tw.Len())
+
+	// The held timer that closed is not executed.
+	closed.Close()
+	require.Equal(t, 2, tw.Len())
+
+	tw.Resume()
+	require.False(t, tw.Paused())
+	require.Equal(t, 0, tw.Held())
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) == 2
+	}, time.Second, time.Millisecond*5)
+	require.Equal(t, 0, tw.Len())
+}
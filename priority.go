@@ -0,0 +1,132 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// priorityItem is a job queued in PriorityExecutor.
+type priorityItem struct {
+	// The rank of the job, the smaller runs first. With aging, it's the enqueued time
+	// minus priority*aging in nanoseconds, thus the job waits for an aging gains the
+	// same as one more priority. Without aging, it's the negative priority.
+	rank int64
+	seq  uint64 // The enqueued order, for the jobs with the same rank.
+	fn   func()
+}
+
+// priorityHeap implements heap.Interface, ordered by rank then seq.
+type priorityHeap []priorityItem
+
+func (h priorityHeap) Len() int { return len(h) }
+func (h priorityHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].seq < h[j].seq
+}
+func (h priorityHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *priorityHeap) Push(x interface{}) { *h = append(*h, x.(priorityItem)) }
+func (h *priorityHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = priorityItem{}
+	*h = old[:n-1]
+	return item
+}
+
+// PriorityExecutor is an Executor that runs the jobs by a fixed number of workers,
+// the queued jobs with higher priority set by WithPriority run first when all
+// workers are busy.
+//
+// To prevent the low priority jobs from starvation, the priority of a queued job
+// is increased by one for each aging it waits. The aging of 0 means no aging.
+type PriorityExecutor struct {
+	aging time.Duration
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  priorityHeap
+	seq    uint64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewPriorityExecutor creates a PriorityExecutor with the given number of workers,
+// workers must >= 1.
+func NewPriorityExecutor(workers int, aging time.Duration) *PriorityExecutor {
+	if workers < 1 {
+		panic("timewheel: workers must be greater than 0")
+	}
+
+	e := &PriorityExecutor{aging: aging}
+	e.cond = sync.NewCond(&e.mu)
+	e.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go e.work()
+	}
+	return e
+}
+
+// Execute implements Executor.
+func (e *PriorityExecutor) Execute(t *Timer, fn func()) {
+	rank := -int64(t.priority)
+	if e.aging > 0 {
+		rank = time.Now().UnixNano() - int64(t.priority)*int64(e.aging)
+	}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		go fn()
+		return
+	}
+	e.seq++
+	heap.Push(&e.queue, priorityItem{rank: rank, seq: e.seq, fn: fn})
+	e.mu.Unlock()
+	e.cond.Signal()
+}
+
+// work runs the queued jobs until the executor is closed and the queue is empty.
+func (e *PriorityExecutor) work() {
+	defer e.wg.Done()
+
+	for {
+		e.mu.Lock()
+		for len(e.queue) == 0 && !e.closed {
+			e.cond.Wait()
+		}
+		if len(e.queue) == 0 {
+			e.mu.Unlock()
+			return
+		}
+		item := heap.Pop(&e.queue).(priorityItem)
+		e.mu.Unlock()
+
+		item.fn()
+	}
+}
+
+// Len returns the number of queued jobs, it's reported in Stats.
+func (e *PriorityExecutor) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.queue)
+}
+
+// Close stops the workers after the queued jobs are done, and waits for them. The
+// jobs executed after Close run in their own goroutines.
+func (e *PriorityExecutor) Close() {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+	e.cond.Broadcast()
+	e.wg.Wait()
+}
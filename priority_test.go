@@ -0,0 +1,79 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runPriorities blocks the only worker of e, then executes the timers with the
+// priorities and returns the order they ran.
+func runPriorities(e *PriorityExecutor, priorities []int, interval time.Duration) []int {
+	startC := make(chan struct{})
+	blockC := make(chan struct{})
+	e.Execute(&Timer{}, func() {
+		close(startC)
+		<-blockC
+	})
+	<-startC
+
+	var mu sync.Mutex
+	var got []int
+	var wg sync.WaitGroup
+	for _, p := range priorities {
+		p := p
+		wg.Add(1)
+		e.Execute(&Timer{priority: p}, func() {
+			mu.Lock()
+			got = append(got, p)
+			mu.Unlock()
+			wg.Done()
+		})
+		time.Sleep(interval)
+	}
+	close(blockC)
+	wg.Wait()
+	return got
+}
+
+func TestPriorityExecutor(t *testing.T) {
+	e := NewPriorityExecutor(1, 0)
+	defer e.Close()
+
+	got := runPriorities(e, []int{0, 10, 5, 10, -1}, 0)
+	require.Equal(t, []int{10, 10, 5, 0, -1}, got)
+	require.Equal(t, 0, e.Len())
+}
+
+func TestPriorityExecutor_Aging(t *testing.T) {
+	e := NewPriorityExecutor(1, time.Millisecond)
+	defer e.Close()
+
+	// The low priority job has waited for more than 2 agings.
+	got := runPriorities(e, []int{0, 2}, time.Millisecond*10)
+	require.Equal(t, []int{0, 2}, got)
+
+	got = runPriorities(e, []int{0, 100}, time.Millisecond*10)
+	require.Equal(t, []int{100, 0}, got)
+}
+
+func TestPriorityExecutor_Close(t *testing.T) {
+	tw := Default(WithExecutor(NewPriorityExecutor(2, time.Second)))
+	tw.Start()
+	defer tw.Stop()
+
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) error {
+		return nil
+	}, WithPriority(3))
+	<-timer.Done()
+	require.Equal(t, 3, timer.Priority())
+
+	e := NewPriorityExecutor(2, 0)
+	e.Close()
+	doneC := make(chan struct{})
+	e.Execute(&Timer{}, func() { close(doneC) })
+	<-doneC
+}
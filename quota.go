@@ -0,0 +1,185 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import "errors"
+
+// ErrQuotaExceeded is returned when a timer is created with a tag whose pending
+// timers reach the MaxPending of its Quota.
+var ErrQuotaExceeded = errors.New("timewheel: quota exceeded")
+
+// Quota limits the timers with a tag, e.g. the tag of a tenant. Zero means no limit.
+type Quota struct {
+	// MaxPending is the max number of pending timers with the tag. The timer created
+	// beyond it is rejected with ErrQuotaExceeded.
+	MaxPending int `json:"max_pending,omitempty" yaml:"max_pending,omitempty"`
+
+	// MaxRunning is the max number of jobs with the tag that run concurrently. The
+	// expired timer beyond it is deferred until a running job with the tag completes,
+	// thus the other tags are not affected.
+	MaxRunning int `json:"max_running,omitempty" yaml:"max_running,omitempty"`
+}
+
+// quotaState is the usage of a tag that has Quota.
+type quotaState struct {
+	Quota
+	pending  int // The number of pending timers, see TimeWheel.Len.
+	running  int
+	deferred []*quotaRun // FIFO
+}
+
+// quotaRun is a run of a timer's job that needs the running slots of its tags.
+type quotaRun struct {
+	t         *Timer
+	at        int64  // The scheduled time of the run, in milliseconds.
+	fn        func() // Runs the job, it's called by the Executor.
+	triggered bool   // Whether the run is started by Timer.Trigger, then t is still pending.
+}
+
+// limited reports whether t has any tag, and tw has any Quota.
+func (tw *TimeWheel) limited(t *Timer) bool {
+	return len(tw.quotas) != 0 && len(t.tags) != 0
+}
+
+// pendTags adds delta to the pending timers of t's tags that have Quota.
+func (tw *TimeWheel) pendTags(t *Timer, delta int) {
+	if !tw.limited(t) {
+		return
+	}
+	tw.quotaMu.Lock()
+	for _, tag := range t.tags {
+		if q, ok := tw.quotas[tag]; ok {
+			q.pending += delta
+		}
+	}
+	tw.quotaMu.Unlock()
+}
+
+// pendingByTag returns the number of pending timers with tag that has Quota.
+func (tw *TimeWheel) pendingByTag(tag string) int {
+	tw.quotaMu.Lock()
+	defer tw.quotaMu.Unlock()
+	if q, ok := tw.quotas[tag]; ok {
+		return q.pending
+	}
+	return 0
+}
+
+// acquire takes a running slot of each tag of the timer of r. If any tag reaches its
+// MaxRunning, nothing is taken and r is deferred to the tag, it returns false.
+//
+// The quotaMu must be held.
+func (tw *TimeWheel) acquire(r *quotaRun) bool {
+	for _, tag := range r.t.tags {
+		if q, ok := tw.quotas[tag]; ok && q.MaxRunning > 0 && q.running >= q.MaxRunning {
+			q.deferred = append(q.deferred, r)
+			return false
+		}
+	}
+	for _, tag := range r.t.tags {
+		if q, ok := tw.quotas[tag]; ok {
+			q.running++
+		}
+	}
+	return true
+}
+
+// acquireRun is like acquire, but locks quotaMu and always returns true if the timer
+// of r is not limited by any Quota.
+func (tw *TimeWheel) acquireRun(r *quotaRun) bool {
+	if !tw.limited(r.t) {
+		return true
+	}
+	tw.quotaMu.Lock()
+	ok := tw.acquire(r)
+	tw.quotaMu.Unlock()
+	if !ok {
+		tw.logger.Debug("timewheel: timer deferred by quota", "timer_id", r.t.id)
+	}
+	return ok
+}
+
+// execute hands t to the Executor to run its job once it gets the running slots of
+// its tags, or defers t until them are released.
+func (tw *TimeWheel) execute(t *Timer) {
+	tw.executeRun(&quotaRun{t: t, at: t.expiration, fn: func() { tw.run(t) }})
+}
+
+// executeRun hands r to the Executor once it gets the running slots of the tags of
+// its timer, or defers r until them are released.
+func (tw *TimeWheel) executeRun(r *quotaRun) {
+	if !tw.limited(r.t) {
+		tw.handoff(r.t, r.at, r.fn)
+		return
+	}
+	if tw.acquireRun(r) {
+		tw.handoffRun(r)
+	}
+}
+
+// handoffRun hands r that has got its running slots to the Executor, the slots are
+// released after it returns.
+func (tw *TimeWheel) handoffRun(r *quotaRun) {
+	tw.handoff(r.t, r.at, func() {
+		r.fn()
+		tw.release(r.t)
+	})
+}
+
+// release gives back the running slots of t's tags, and executes the runs deferred
+// to them in order, as long as they can get their slots.
+func (tw *TimeWheel) release(t *Timer) {
+	var ready []*quotaRun
+
+	tw.quotaMu.Lock()
+	for _, tag := range t.tags {
+		if q, ok := tw.quotas[tag]; ok {
+			q.running--
+		}
+	}
+	for _, tag := range t.tags {
+		q, ok := tw.quotas[tag]
+		if !ok {
+			continue
+		}
+		for len(q.deferred) != 0 && (q.MaxRunning <= 0 || q.running < q.MaxRunning) {
+			next := q.deferred[0]
+			q.deferred[0] = nil
+			q.deferred = q.deferred[1:]
+			// The next may be deferred again to another tag of it.
+			if tw.acquire(next) {
+				ready = append(ready, next)
+			}
+		}
+	}
+	tw.quotaMu.Unlock()
+
+	for _, next := range ready {
+		tw.handoffRun(next)
+	}
+}
+
+// RunningByTag returns the number of running jobs with tag, it only counts the tags
+// that have Quota.
+func (tw *TimeWheel) RunningByTag(tag string) int {
+	tw.quotaMu.Lock()
+	defer tw.quotaMu.Unlock()
+	if q, ok := tw.quotas[tag]; ok {
+		return q.running
+	}
+	return 0
+}
+
+// DeferredByTag returns the number of runs that are waiting for the running slots of
+// tag, including the ones started by Timer.Trigger.
+func (tw *TimeWheel) DeferredByTag(tag string) int {
+	tw.quotaMu.Lock()
+	defer tw.quotaMu.Unlock()
+	if q, ok := tw.quotas[tag]; ok {
+		return len(q.deferred)
+	}
+	return 0
+}
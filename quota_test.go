@@ -0,0 +1,247 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_Quota_MaxPending(t *testing.T) {
+	tw := Default(WithTagQuota("tenant-a", Quota{MaxPending: 2}))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	job := func(ctx context.Context) error { return nil }
+
+	t1 := tw.TimeFunc(ctx, at, job, WithTags("tenant-a"))
+	t2 := tw.TimeFunc(ctx, at, job, WithTags("tenant-a"))
+	require.Equal(t, 2, tw.CountByTag("tenant-a"))
+
+	t3 := tw.TimeFunc(ctx, at, job, WithTags("tenant-a"))
+	<-t3.Done()
+	require.True(t, errors.Is(t3.Err(), ErrQuotaExceeded))
+	require.Equal(t, 2, tw.CountByTag("tenant-a"))
+	require.Equal(t, 2, tw.Len())
+
+	t4 := tw.ScheduleJob(ctx, Every(time.Hour), JobFunc(job), WithTags("tenant-a"))
+	require.True(t, errors.Is(t4.Err(), ErrQuotaExceeded))
+
+	// The other tags are not limited.
+	for i := 0; i < 5; i++ {
+		tw.TimeFunc(ctx, at, job, WithTags("tenant-b"))
+	}
+	require.Equal(t, 5, tw.CountByTag("tenant-b"))
+
+	// The slot is freed after the timer is closed.
+	t1.Close()
+	t5 := tw.TimeFunc(ctx, at, job, WithTags("tenant-a"))
+	require.Nil(t, t5.Err())
+	require.Equal(t, 2, tw.CountByTag("tenant-a"))
+
+	t2.Close()
+	t5.Close()
+}
+
+func TestTimeWheel_Quota_MaxRunning(t *testing.T) {
+	tw := Default(WithTagQuota("tenant-a", Quota{MaxRunning: 1}))
+	tw.Start()
+	defer tw.Stop()
+
+	var running, maxRunning, runs int32
+	blockC := make(chan struct{})
+	job := func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		<-blockC
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(5 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		tw.TimeFunc(ctx, at, job, WithTags("tenant-a"))
+	}
+	require.Eventually(t, func() bool { return tw.DeferredByTag("tenant-a") == 2 }, time.Second, time.Millisecond)
+	require.Equal(t, 1, tw.RunningByTag("tenant-a"))
+
+	// The other tags are not blocked by the deferred timers.
+	freeC := make(chan struct{})
+	tw.TimeFunc(ctx, at, func(ctx context.Context) error {
+		close(freeC)
+		return nil
+	}, WithTags("tenant-b"))
+	<-freeC
+
+	close(blockC)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+	require.Equal(t, 0, tw.RunningByTag("tenant-a"))
+	require.Equal(t, 0, tw.DeferredByTag("tenant-a"))
+}
+
+func TestTimeWheel_Quota_Named(t *testing.T) {
+	tw := Default(WithTagQuota("tenant-a", Quota{MaxPending: 1}))
+	tw.RegisterJob("noop", func(payload []byte) (Job, error) {
+		return JobFunc(func(ctx context.Context) error { return nil }), nil
+	})
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	timer, err := tw.TimeNamed(ctx, at, "noop", nil, WithTags("tenant-a"))
+	require.Nil(t, err)
+	defer timer.Close()
+
+	_, err = tw.TimeNamed(ctx, at, "noop", nil, WithTags("tenant-a"))
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+}
+
+func TestTimeWheel_Quota_Upsert(t *testing.T) {
+	tw := Default(WithTagQuota("a", Quota{MaxPending: 1}))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	job := func(ctx context.Context) error { return nil }
+
+	t1 := tw.Upsert(ctx, "k", at, job, WithTags("a"))
+	require.Nil(t, t1.Err())
+
+	// Replacing the timer of the same key keeps the count of tag.
+	t2 := tw.Upsert(ctx, "k", at, job, WithTags("a"))
+	require.Nil(t, t2.Err())
+	require.Equal(t, ErrTimerClosed, t1.Err())
+	require.Equal(t, 1, tw.CountByTag("a"))
+
+	// The other keys are still limited.
+	t3 := tw.Upsert(ctx, "k2", at, job, WithTags("a"))
+	require.True(t, errors.Is(t3.Err(), ErrQuotaExceeded))
+	t2.Close()
+}
+
+func TestTimeWheel_Quota_MaxPending_Running(t *testing.T) {
+	tw := Default(WithTagQuota("a", Quota{MaxPending: 1}))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	startC, blockC := make(chan struct{}), make(chan struct{})
+	t1 := tw.TimeFunc(ctx, time.Now(), func(ctx context.Context) error {
+		close(startC)
+		<-blockC
+		return nil
+	}, WithTags("a"))
+	<-startC
+
+	// The one-shot timer whose job is running is no longer pending.
+	t2 := tw.TimeFunc(ctx, time.Now().Add(time.Hour), func(ctx context.Context) error { return nil }, WithTags("a"))
+	require.Nil(t, t2.Err())
+	require.Equal(t, 2, tw.CountByTag("a"))
+
+	t3 := tw.TimeFunc(ctx, time.Now().Add(time.Hour), func(ctx context.Context) error { return nil }, WithTags("a"))
+	require.True(t, errors.Is(t3.Err(), ErrQuotaExceeded))
+
+	close(blockC)
+	<-t1.Done()
+	t2.Close()
+	require.Equal(t, 0, tw.Len())
+}
+
+func TestTimeWheel_Quota_Trigger(t *testing.T) {
+	tw := Default(WithTagQuota("a", Quota{MaxRunning: 1}))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	startC, blockC := make(chan struct{}), make(chan struct{})
+	t1 := tw.TimeFunc(ctx, time.Now(), func(ctx context.Context) error {
+		close(startC)
+		<-blockC
+		return nil
+	}, WithTags("a"))
+	<-startC
+
+	var runs int32
+	t2 := tw.TimeFunc(ctx, time.Now().Add(time.Hour), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithTags("a"))
+	defer t2.Close()
+
+	// The triggered run waits for the running slot of tag.
+	errC := make(chan error, 1)
+	go func() { errC <- t2.Trigger(ctx) }()
+	require.Eventually(t, func() bool { return tw.DeferredByTag("a") == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, int32(0), atomic.LoadInt32(&runs))
+
+	// The timer of the triggered run is listed once.
+	n := 0
+	tw.Range(func(info TimerInfo) bool {
+		n++
+		require.False(t, info.Deferred)
+		return true
+	})
+	require.Equal(t, 1, n)
+
+	close(blockC)
+	require.Nil(t, <-errC)
+	<-t1.Done()
+	require.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	require.Eventually(t, func() bool { return tw.RunningByTag("a") == 0 }, time.Second, time.Millisecond)
+	require.Equal(t, 1, tw.Len())
+}
+
+func TestTimeWheel_Quota_Batch(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]*Timer
+	blockC := make(chan struct{})
+	tw := Default(WithTagQuota("a", Quota{MaxRunning: 1}), WithBatchHandler(func(expired []*Timer) {
+		mu.Lock()
+		batches = append(batches, append([]*Timer(nil), expired...))
+		first := len(batches) == 1
+		mu.Unlock()
+		if first {
+			<-blockC
+		}
+	}))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Millisecond * 50)
+	job := func(ctx context.Context) error { return nil }
+	t1 := tw.TimeFunc(ctx, at, job, WithTags("a"))
+	t2 := tw.TimeFunc(ctx, at, job, WithTags("a"))
+	t3 := tw.TimeFunc(ctx, at, job, WithTags("b"))
+
+	// The timers beyond the MaxRunning are deferred, the others are not blocked.
+	require.Eventually(t, func() bool { return tw.DeferredByTag("a") == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, 1, tw.RunningByTag("a"))
+
+	close(blockC)
+	<-t1.Done()
+	<-t2.Done()
+	<-t3.Done()
+	require.Eventually(t, func() bool { return tw.RunningByTag("a") == 0 }, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, len(batches))
+	require.Equal(t, 2, len(batches[0]))
+	require.Equal(t, 1, len(batches[1]))
+	require.Contains(t, batches[0], t3)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrJobTypeNotRegistered is returned when the job type name has not been registered.
+var ErrJobTypeNotRegistered = errors.New("timewheel: job type not registered")
+
+// JobFactory creates a Job from its serialized payload.
+type JobFactory func(payload []byte) (Job, error)
+
+// JSONFactory returns a JobFactory that decodes the JSON payload into the Job
+// allocated by newJob, e.g.
+//
+//	type SendEmail struct{ To string `json:"to"` }
+//	func (j *SendEmail) Run(ctx context.Context) error { ... }
+//
+//	registry.Register("send-email", JSONFactory(func() Job { return new(SendEmail) }))
+func JSONFactory(newJob func() Job) JobFactory {
+	return func(payload []byte) (Job, error) {
+		job := newJob()
+		if len(payload) != 0 {
+			if err := json.Unmarshal(payload, job); err != nil {
+				return nil, err
+			}
+		}
+		return job, nil
+	}
+}
+
+// Registry maps the job type names to their factories. Thus a job can be referred
+// by its type name and payload instead of an opaque func, and be serialized,
+// listed and recreated.
+//
+// A Registry can be shared by multiple TimeWheel with the WithRegistry option.
+type Registry struct {
+	factories map[string]JobFactory
+	mu        *sync.RWMutex
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]JobFactory),
+		mu:        new(sync.RWMutex),
+	}
+}
+
+// Register registers the factory of the job type name.
+// Registering the same name again replaces the previous factory.
+func (r *Registry) Register(name string, factory JobFactory) {
+	r.mu.Lock()
+	r.factories[name] = factory
+	r.mu.Unlock()
+}
+
+// RegisterFunc registers the job type name whose job calls fn with the payload.
+func (r *Registry) RegisterFunc(name string, fn func(ctx context.Context, payload []byte) error) {
+	r.Register(name, func(payload []byte) (Job, error) {
+		return JobFunc(func(ctx context.Context) error {
+			return fn(ctx, payload)
+		}), nil
+	})
+}
+
+// Names returns the sorted names of all registered job types.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// New creates the Job of the job type name with the payload.
+func (r *Registry) New(name string, payload []byte) (Job, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrJobTypeNotRegistered, name)
+	}
+	return factory(payload)
+}
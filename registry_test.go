@@ -0,0 +1,52 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type registryJob struct {
+	To string `json:"to"`
+}
+
+func (j *registryJob) Run(ctx context.Context) error {
+	return errors.New(j.To)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.Equal(t, []string{}, r.Names())
+
+	_, err := r.New("send-email", nil)
+	require.True(t, errors.Is(err, ErrJobTypeNotRegistered))
+
+	r.Register("send-email", JSONFactory(func() Job { return new(registryJob) }))
+	r.RegisterFunc("echo", func(ctx context.Context, payload []byte) error {
+		return errors.New(string(payload))
+	})
+	require.Equal(t, []string{"echo", "send-email"}, r.Names())
+
+	job, err := r.New("send-email", []byte(`{"to":"a@b.c"}`))
+	require.Nil(t, err)
+	require.Equal(t, "a@b.c", job.Run(context.Background()).Error())
+
+	_, err = r.New("send-email", []byte(`{`))
+	require.NotNil(t, err)
+
+	job, err = r.New("echo", []byte("hello"))
+	require.Nil(t, err)
+	require.Equal(t, "hello", job.Run(context.Background()).Error())
+}
+
+func TestWithRegistry(t *testing.T) {
+	r := NewRegistry()
+	tw1 := Default(WithRegistry(r))
+	tw2 := Default(WithRegistry(r))
+
+	tw1.RegisterJob("echo", JSONFactory(func() Job { return new(registryJob) }))
+	require.Equal(t, r, tw2.Registry())
+	require.Equal(t, []string{"echo"}, tw2.Registry().Names())
+}
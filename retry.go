@@ -0,0 +1,136 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy decides whether and when to retry a failed run of job, see WithRetry.
+type RetryPolicy struct {
+	// The max number of runs for each expiration, including the first one.
+	// No retry if it's less than 2.
+	MaxAttempts int
+	// The backoff before the first retry.
+	InitialBackoff time.Duration
+	// The upper bound of backoff, 0 means no limit.
+	MaxBackoff time.Duration
+	// The factor by which the backoff grows after each retry. The backoff is
+	// constant if it's less than 1.
+	Multiplier float64
+	// The fraction of backoff to randomize in both directions, in [0, 1].
+	// e.g. 0.1 makes the backoff in [0.9*backoff, 1.1*backoff].
+	Jitter float64
+	// Retryable reports whether the err is worth retrying, nil means all errors.
+	Retryable func(err error) bool
+}
+
+// backoff returns the backoff before the nth retry, n starts from 1.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	if p.Multiplier > 1 {
+		d *= math.Pow(p.Multiplier, float64(n-1))
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// retry schedules t to retry its failed job according to its RetryPolicy. The last
+// reports whether the failed run is the last execution of t. It returns false if
+// no retry is scheduled.
+//
+// The timer itself is resubmitted for retry. For a recurring timer, it's moved from
+// the next cycle to the retry time, and goes back to the next cycle after the retry.
+// The retry is dropped if it's not earlier than the next cycle, that supersedes it.
+func (tw *TimeWheel) retry(t *Timer, err error, last bool) bool {
+	p := t.retry
+	if p == nil || err == ErrOverlap || atomic.LoadInt32(&t.closed) == 1 {
+		return false
+	}
+	if p.Retryable != nil && !p.Retryable(err) {
+		return false
+	}
+
+	n, at, ok := tw.retryAt(t, p, last)
+	if !ok {
+		return false
+	}
+
+	t.stats.retry()
+	tw.logger.Debug("timewheel: job retry scheduled", "timer_id", t.id, "attempt", n+1, "at", msToTime(at))
+
+	tw.submit(t)
+	if atomic.LoadInt32(&t.closed) == 1 {
+		// The timer is closed while it's being resubmitted, it may have been
+		// finished before pending again.
+		tw.finish(t)
+		tw.unpend(t)
+		t.resolve(ErrTimerClosed)
+	}
+	return true
+}
+
+// retryAt moves the expiration of t to the time of its next retry with cycleMu held,
+// it returns the number of failed runs, the time of retry, and false if no retry
+// is scheduled.
+func (tw *TimeWheel) retryAt(t *Timer, p *RetryPolicy, last bool) (int, int64, bool) {
+	t.cycleMu.Lock()
+	defer t.cycleMu.Unlock()
+
+	n := int(atomic.AddInt32(&t.attempt, 1))
+	if n >= p.MaxAttempts {
+		return n, 0, false
+	}
+	at := timeToMs(time.Now().Add(p.backoff(n)))
+
+	if last {
+		t.nextCycle = 0
+		if t.sh == nil {
+			// The one-shot timer is pending again until the retry.
+			tw.pend(t)
+		}
+	} else {
+		if at >= t.expiration {
+			return n, 0, false
+		}
+		if b := t.getBucket(); b == nil || !b.detach(t) {
+			// The next cycle is expiring now.
+			return n, 0, false
+		}
+		t.nextCycle = t.expiration
+	}
+
+	t.expiration = at
+	atomic.StoreInt32(&t.retrying, 1)
+	if err := tw.persist(t); err != nil {
+		tw.logger.Error("timewheel: persist timer failed", "timer_id", t.id, "error", err)
+	}
+	return n, at, true
+}
+
+// resumeCycle moves t that expires for retry back to its next cycle without submitting
+// it, returns false if there is no next cycle. The cycleMu of t must be held.
+func (tw *TimeWheel) resumeCycle(t *Timer) bool {
+	if t.nextCycle == 0 {
+		return false
+	}
+	t.expiration = t.nextCycle
+	if err := tw.persist(t); err != nil {
+		tw.logger.Error("timewheel: persist timer failed", "timer_id", t.id, "error", err)
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: time.Millisecond * 10,
+		MaxBackoff:     time.Millisecond * 50,
+		Multiplier:     2,
+	}
+	require.Equal(t, time.Millisecond*10, p.backoff(1))
+	require.Equal(t, time.Millisecond*20, p.backoff(2))
+	require.Equal(t, time.Millisecond*40, p.backoff(3))
+	require.Equal(t, time.Millisecond*50, p.backoff(4))
+
+	p.Multiplier = 0
+	require.Equal(t, time.Millisecond*10, p.backoff(3))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		require.GreaterOrEqual(t, d, time.Millisecond*5)
+		require.LessOrEqual(t, d, time.Millisecond*15)
+	}
+}
+
+// runRecorder records the start time of each run of job.
+type runRecorder struct {
+	mu   sync.Mutex
+	runs []time.Time
+	errs []error // The errors returned by runs in order, nil for the rest.
+}
+
+func (r *runRecorder) Run(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, time.Now())
+	if n := len(r.runs); n <= len(r.errs) {
+		return r.errs[n-1]
+	}
+	return nil
+}
+
+func (r *runRecorder) get() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Time(nil), r.runs...)
+}
+
+func TestWithRetry(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	failed := errors.New("failed")
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond * 10}
+
+	// Succeeds at the last attempt.
+	r1 := &runRecorder{errs: []error{failed, failed}}
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), r1.Run, WithRetry(policy))
+	require.Eventually(t, func() bool { return len(r1.get()) == 3 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return tw.Len() == 0 }, time.Second, time.Millisecond)
+	runs := r1.get()
+	require.GreaterOrEqual(t, runs[2].Sub(runs[1]), time.Millisecond*9)
+	stats := t1.Stats()
+	require.Equal(t, uint64(3), stats.Runs)
+	require.Equal(t, uint64(2), stats.Failures)
+	require.Equal(t, uint64(2), stats.Retries)
+	require.NoError(t, stats.LastError)
+
+	// Gives up after max attempts.
+	r2 := &runRecorder{errs: []error{failed, failed, failed, failed}}
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), r2.Run, WithRetry(policy))
+	require.Eventually(t, func() bool { return t2.Stats().Runs == 3 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 50)
+	require.Equal(t, 3, len(r2.get()))
+	require.Equal(t, 0, tw.Len())
+
+	// Not retryable.
+	policy.Retryable = func(err error) bool { return err != failed }
+	r3 := &runRecorder{errs: []error{failed}}
+	t3 := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), r3.Run, WithRetry(policy))
+	require.Eventually(t, func() bool { return t3.Stats().Runs == 1 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 30)
+	require.Equal(t, 1, len(r3.get()))
+	require.Equal(t, uint64(0), t3.Stats().Retries)
+}
+
+func TestWithRetry_Close(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	// The retry is far enough to never fire during the test.
+	r := &runRecorder{errs: []error{errors.New("failed")}}
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(-time.Second), r.Run,
+		WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}))
+	require.Eventually(t, func() bool { return timer.Stats().Retries == 1 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return timer.getBucket() != nil }, time.Second, time.Millisecond)
+	require.Equal(t, 1, tw.Len())
+
+	timer.Close()
+	require.Equal(t, 0, tw.Len())
+	<-timer.Done()
+	require.Equal(t, ErrTimerClosed, timer.Err())
+	require.Equal(t, 1, len(r.get()))
+}
+
+func TestWithRetry_Recurring(t *testing.T) {
+	tw := Default(WithHistory(8))
+	tw.Start()
+	defer tw.Stop()
+
+	failed := errors.New("failed")
+	r := &runRecorder{errs: []error{failed, nil, failed}}
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond*100), r,
+		WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond * 20}))
+	defer timer.Close()
+
+	require.Eventually(t, func() bool { return len(timer.History()) == 5 }, time.Second*2, time.Millisecond)
+	history := timer.History()
+
+	// The regular cycles are not shifted by the retries. The scheduled times are
+	// compared instead of the actual start times, which depend on the load.
+	for _, i := range []int{2, 4} {
+		require.Equal(t, time.Millisecond*100, history[i].Scheduled.Sub(history[i-2].Scheduled), "cycle %d", i/2)
+	}
+	// The retries run between the regular cycles.
+	for _, i := range []int{1, 3} {
+		require.GreaterOrEqual(t, history[i].Scheduled.Sub(history[i-1].Scheduled), time.Millisecond*19, "retry %d", i)
+		require.True(t, history[i].Scheduled.Before(history[i+1].Scheduled), "retry %d", i)
+		require.False(t, history[i].Start.Before(history[i-1].Start), "retry %d", i)
+	}
+	require.Equal(t, failed, history[0].Err)
+	require.Nil(t, history[1].Err)
+	require.Equal(t, 1, tw.Len())
+	require.Equal(t, uint64(2), timer.Stats().Retries)
+}
+
+func TestWithRetry_Recurring_Superseded(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	r := &runRecorder{errs: []error{errors.New("failed")}}
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond*20), r,
+		WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond * 50}))
+	defer timer.Close()
+
+	require.Eventually(t, func() bool { return len(r.get()) >= 2 }, time.Second, time.Millisecond)
+	runs := r.get()
+	require.Less(t, runs[1].Sub(runs[0]), time.Millisecond*40)
+	// The retry is dropped, since the next cycle comes first.
+	require.Equal(t, uint64(0), timer.Stats().Retries)
+}
+
+func TestWithRetry_Recurring_SlowRetry(t *testing.T) {
+	tw := Default(WithHistory(16))
+	tw.Start()
+	defer tw.Stop()
+
+	// The retry runs last longer than the interval, the next cycles expire while
+	// they are running.
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond*5), JobFunc(func(ctx context.Context) error {
+		time.Sleep(time.Millisecond * 10)
+		return errors.New("failed")
+	}), WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	require.Eventually(t, func() bool { return timer.Stats().Retries >= 4 }, time.Second*2, time.Millisecond)
+	timer.Close()
+	<-timer.Done()
+	require.Eventually(t, func() bool { return tw.Len() == 0 }, time.Second, time.Millisecond)
+}
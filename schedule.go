@@ -7,6 +7,8 @@ package timewheel
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,10 +27,20 @@ func (f JobFunc) Run(ctx context.Context) error {
 // Job used to execute job.
 type Job interface {
 	// Run will be called when schedule expired.
-	// Notice: timewheel will not process any errors, And only gives it to the invoker.
+	// Notice: timewheel will not process any errors, And only reports them to the Logger and Observers.
 	Run(ctx context.Context) error
 }
 
+// PanicError is the error reported to the Logger and Observers when a job panics.
+type PanicError struct {
+	Value interface{} // The value passed to panic.
+	Stack []byte      // The stack trace of the panicking goroutine.
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("timewheel: job panic: %v", e.Value)
+}
+
 // ScheduleFunc is a type adapter that turns a function into an Schedule.
 type ScheduleFunc func(t time.Time) time.Time
 
@@ -57,53 +69,112 @@ type Schedule interface {
 // Afterwards, it will ask the next execution time each time jobFunc is about to
 // be executed, and jobFunc will be called at the next execution time if the time
 // is non-zero.
-func (tw *TimeWheel) ScheduleJob(ctx context.Context, sh Schedule, job Job) *Timer {
-	ctxCancel, cancelFunc := context.WithCancel(ctx)
-	timer := &Timer{
-		ctxCancel:  ctxCancel,
-		cancelFunc: cancelFunc,
-		expiration: 0,
-		jobFunc:    nil,
-		b:          nil,
-		element:    nil,
-	}
+func (tw *TimeWheel) ScheduleJob(ctx context.Context, sh Schedule, job Job, opts ...TimerOption) *Timer {
+	timer, _ := tw.scheduleJob(ctx, sh, job, opts, false)
+	return timer
+}
+
+// scheduleJob implements ScheduleJob, it always returns the timer even if it's
+// rejected by admit.
+func (tw *TimeWheel) scheduleJob(ctx context.Context, sh Schedule, job Job, opts []TimerOption, wait bool) (*Timer, error) {
+	timer := tw.newTimer(ctx, 0, nil, opts)
+	timer.sh = sh
 
 	next1 := sh.Next(time.Now().In(tw.location))
 	if next1.IsZero() {
 		// No time is scheduled, return empty timer.
-		return timer
+		timer.finished = 1
+		timer.resolve(nil)
+		return timer, nil
 	}
 
 	timer.expiration = timeToMs(next1)
-	timer.jobFunc = func(ctx context.Context) error {
-		// ScheduleJob the jobFunc to execute at the next time if possible.
-		next2 := sh.Next(msToTime(timer.expiration).In(tw.location))
-		if !next2.IsZero() {
-			// Resubmit the timer to next cycle.
-			timer.expiration = timeToMs(next2)
-			tw.submit(timer)
-		}
-		return job.Run(ctx)
+	if job != nil {
+		// The job may be nil in batch mode.
+		timer.jobFunc = job.Run
 	}
 
+	if err := tw.admit(timer, wait); err != nil {
+		return timer, err
+	}
+	tw.submitKeyed(timer)
+	return timer, nil
+}
+
+// reschedule resubmits the timer created by ScheduleJob to its next cycle before
+// its job is executed. It returns false if there is no next execution.
+func (tw *TimeWheel) reschedule(timer *Timer) bool {
+	if !tw.nextCycle(timer) {
+		return false
+	}
 	tw.submit(timer)
-	return timer
+	return true
+}
+
+// nextCycle moves the expiration of timer to its next cycle without submitting it.
+// It returns false if there is no next execution.
+func (tw *TimeWheel) nextCycle(timer *Timer) bool {
+	next2 := timer.sh.Next(msToTime(timer.expiration).In(tw.location))
+	if next2.IsZero() {
+		return false
+	}
+
+	if next := timeToMs(next2); next <= timer.expiration {
+		tw.logger.Warn("timewheel: schedule returns non-advancing time",
+			"timer_id", timer.id, "prev", msToTime(timer.expiration), "next", next2)
+	}
+
+	// Resubmit the timer to next cycle.
+	timer.expiration = timeToMs(next2)
+	if err := tw.persist(timer); err != nil {
+		tw.logger.Error("timewheel: persist timer failed", "timer_id", timer.id, "error", err)
+	}
+	return true
 }
 
 // TimeFunc waits until the appointed time and then calls fn in its own goroutine.
 // It returns a Timer that can be used to cancel the call using its Close method.
-func (tw *TimeWheel) TimeFunc(ctx context.Context, t time.Time, fn JobFunc) *Timer {
+//
+// If the timer exceeds the limit set by WithMaxTimers or the Quota of its tags, it's
+// rejected, i.e. the returned Timer is closed and its Err returns ErrTooManyTimers
+// or ErrQuotaExceeded. The same applies to ScheduleJob. See TryTimeFunc for the
+// variant that returns the error.
+func (tw *TimeWheel) TimeFunc(ctx context.Context, t time.Time, fn JobFunc, opts ...TimerOption) *Timer {
+	timer, _ := tw.timeFunc(ctx, t, fn, opts, false)
+	return timer
+}
+
+// timeFunc implements TimeFunc, it always returns the timer even if it's rejected
+// by admit.
+func (tw *TimeWheel) timeFunc(ctx context.Context, t time.Time, fn JobFunc, opts []TimerOption, wait bool) (*Timer, error) {
+	timer := tw.newTimer(ctx, timeToMs(t), fn, opts)
+
+	if err := tw.admit(timer, wait); err != nil {
+		return timer, err
+	}
+	tw.submitKeyed(timer)
+	return timer, nil
+}
+
+// newTimer creates a Timer that belongs to tw, the timer's context is derived from ctx.
+func (tw *TimeWheel) newTimer(ctx context.Context, expiration int64, fn JobFunc, opts []TimerOption) *Timer {
 	ctxCancel, cancelFunc := context.WithCancel(ctx)
 
 	timer := &Timer{
 		ctxCancel:  ctxCancel,
 		cancelFunc: cancelFunc,
-		expiration: timeToMs(t),
+		expiration: expiration,
 		jobFunc:    fn,
 		b:          nil,
 		element:    nil,
+		tw:         tw,
+		id:         atomic.AddUint64(&tw.lastID, 1),
+		created:    timeToMs(time.Now()),
+		done:       make(chan struct{}),
+	}
+	timer.stats.history.size = tw.historySize
+	for _, opt := range opts {
+		opt(timer)
 	}
-
-	tw.submit(timer)
 	return timer
 }
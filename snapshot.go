@@ -0,0 +1,95 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Snapshot writes all pending named timers (created by TimeNamed or ScheduleNamed)
+// to w, one JSON encoded Record per line. The timers created by TimeFunc or ScheduleJob
+// are not serializable and be skipped. The expired timers held by Pause or deferred
+// by quotas are included, they are fired as misfired ones after Restore.
+//
+// It returns ErrScheduleNotSerializable and writes nothing if a named timer has a
+// Schedule that is not a SpecSchedule, since it can not be restored as recurring.
+//
+// The timers that are being moved between levels at the moment may be missed,
+// call it after Stop to get an exact snapshot, e.g. for handing off to another process.
+func (tw *TimeWheel) Snapshot(w io.Writer) error {
+	var records []*Record
+	var err error
+	tw.walk(func(level int, t *Timer) bool {
+		if t.jobType == "" {
+			return true
+		}
+		if _, ok := t.sh.(SpecSchedule); t.sh != nil && !ok {
+			err = fmt.Errorf("timewheel: snapshot timer %d: %w", t.id, ErrScheduleNotSerializable)
+			return false
+		}
+		records = append(records, t.record())
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("timewheel: write snapshot: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("timewheel: write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Restore reads the records written by Snapshot from r and reinserts them into the
+// TimeWheel, the misfired ones are handled according to the MisfirePolicy. The
+// restored timers get new ids, and are saved into the Store if set. The keyed timers
+// replace the pending timers with the same key, like Upsert.
+//
+// Nothing is restored if any record is invalid or its job type has not been registered.
+func (tw *TimeWheel) Restore(r io.Reader) error {
+	var timers []*Timer
+
+	now := timeToMs(time.Now())
+	dec := json.NewDecoder(r)
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("timewheel: read snapshot: %w", err)
+		}
+
+		timer, err := tw.fromRecord(&rec, now)
+		if err != nil {
+			return fmt.Errorf("timewheel: restore timer %d: %w", rec.ID, err)
+		}
+		if timer.finished == 1 {
+			// Discarded by the MisfirePolicy.
+			continue
+		}
+		timers = append(timers, timer)
+	}
+
+	for _, timer := range timers {
+		if err := tw.persist(timer); err != nil {
+			return err
+		}
+		tw.track(timer)
+		tw.submitKeyed(timer)
+	}
+	return nil
+}
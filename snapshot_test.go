@@ -0,0 +1,143 @@
+package timewheel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotRecords(t *testing.T, tw *TimeWheel) []*Record {
+	buf := new(bytes.Buffer)
+	require.Nil(t, tw.Snapshot(buf))
+
+	var records []*Record
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		rec := new(Record)
+		require.Nil(t, json.Unmarshal([]byte(line), rec))
+		rec.ID = 0
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Expiration < records[j].Expiration })
+	return records
+}
+
+func TestTimeWheel_Snapshot_Restore(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("echo", func(ctx context.Context, payload []byte) error { return nil })
+
+	tw1 := New(time.Millisecond, 8, WithRegistry(registry))
+	now := time.Now()
+	for _, d := range []time.Duration{time.Second, time.Hour, time.Hour * 24 * 10} {
+		_, err := tw1.TimeNamed(context.Background(), now.Add(d), "echo", []byte(d.String()))
+		require.Nil(t, err)
+	}
+	_, err := tw1.ScheduleNamed(context.Background(), Every(time.Minute), "echo", nil)
+	require.Nil(t, err)
+	// Not serializable.
+	tw1.TimeFunc(context.Background(), now.Add(time.Second), func(ctx context.Context) error { return nil })
+
+	records := snapshotRecords(t, tw1)
+	require.Equal(t, 4, len(records))
+	require.Equal(t, "1s", string(records[0].Payload))
+	require.Equal(t, "@every 1m0s", records[1].Spec)
+	require.Equal(t, "1h0m0s", string(records[2].Payload))
+	require.Equal(t, "240h0m0s", string(records[3].Payload))
+
+	buf := new(bytes.Buffer)
+	require.Nil(t, tw1.Snapshot(buf))
+
+	tw2 := New(time.Millisecond, 8, WithRegistry(registry))
+	require.Nil(t, tw2.Restore(buf))
+	require.Equal(t, records, snapshotRecords(t, tw2))
+}
+
+func TestTimeWheel_Restore_Error(t *testing.T) {
+	tw := Default()
+
+	err := tw.Restore(strings.NewReader(`{"id":1,"job_type":"echo","expiration":1}`))
+	require.True(t, errors.Is(err, ErrJobTypeNotRegistered))
+
+	err = tw.Restore(strings.NewReader(`{"id":1,`))
+	require.NotNil(t, err)
+
+	tw.Registry().RegisterFunc("echo", func(ctx context.Context, payload []byte) error { return nil })
+	err = tw.Restore(strings.NewReader(`{"id":1,"job_type":"echo","expiration":1,"spec":"x"}`))
+	require.NotNil(t, err)
+}
+
+func TestTimeWheel_Snapshot_Held(t *testing.T) {
+	registry := NewRegistry()
+	doneC := make(chan string, 2)
+	registry.RegisterFunc("echo", func(ctx context.Context, payload []byte) error {
+		doneC <- string(payload)
+		return nil
+	})
+
+	tw1 := New(time.Millisecond, 8, WithRegistry(registry))
+	tw1.Start()
+	tw1.Pause()
+	_, err := tw1.TimeNamed(context.Background(), time.Now().Add(5*time.Millisecond), "echo", []byte("held"),
+		WithKey("k1"), WithName("held-timer"))
+	require.Nil(t, err)
+	require.Eventually(t, func() bool { return tw1.Held() == 1 }, time.Second, time.Millisecond)
+	tw1.Stop()
+
+	records := snapshotRecords(t, tw1)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "held", string(records[0].Payload))
+	require.Equal(t, "k1", records[0].Key)
+	require.Equal(t, "held-timer", records[0].Name)
+
+	buf := new(bytes.Buffer)
+	require.Nil(t, tw1.Snapshot(buf))
+
+	tw2 := New(time.Millisecond, 8, WithRegistry(registry))
+	tw2.Start()
+	defer tw2.Stop()
+	tw2.Pause()
+	require.Nil(t, tw2.Restore(buf))
+	timer, ok := tw2.Get("k1")
+	require.True(t, ok)
+	require.Equal(t, "held-timer", timer.Name())
+
+	// The misfired timer fires after Resume.
+	require.Eventually(t, func() bool { return tw2.Held() == 1 }, time.Second, time.Millisecond)
+	tw2.Resume()
+	require.Equal(t, "held", <-doneC)
+}
+
+// customSchedule is a Schedule that is not a SpecSchedule.
+type customSchedule struct{ d time.Duration }
+
+func (s customSchedule) Next(prev time.Time) time.Time { return prev.Add(s.d) }
+
+func TestTimeWheel_Snapshot_NotSerializable(t *testing.T) {
+	tw := Default()
+	tw.RegisterJob("noop", func(payload []byte) (Job, error) {
+		return JobFunc(func(ctx context.Context) error { return nil }), nil
+	})
+
+	_, err := tw.TimeNamed(context.Background(), time.Now().Add(time.Hour), "noop", nil)
+	require.Nil(t, err)
+	timer, err := tw.ScheduleNamed(context.Background(), customSchedule{d: time.Hour}, "noop", nil)
+	require.Nil(t, err)
+
+	buf := new(bytes.Buffer)
+	err = tw.Snapshot(buf)
+	require.True(t, errors.Is(err, ErrScheduleNotSerializable))
+	require.Equal(t, 0, buf.Len())
+
+	// The one-shot named timers are still serializable.
+	timer.Close()
+	require.Equal(t, 1, len(snapshotRecords(t, tw)))
+}
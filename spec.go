@@ -0,0 +1,57 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SpecSchedule is a Schedule that can be serialized to a spec string,
+// and be parsed back by ParseSchedule.
+type SpecSchedule interface {
+	Schedule
+	// Spec returns the spec string of the Schedule.
+	Spec() string
+}
+
+// everySchedule executes the job at fixed interval.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Every returns a SpecSchedule that executes the job every d.
+// The value of d must > 0.
+func Every(d time.Duration) SpecSchedule {
+	if d <= 0 {
+		panic("timewheel: interval must be greater than 0")
+	}
+	return everySchedule{interval: d}
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+func (s everySchedule) Spec() string {
+	return "@every " + s.interval.String()
+}
+
+// ParseSchedule parses the spec string returned by SpecSchedule.Spec.
+//
+// The supported formats:
+//   - "@every <duration>", e.g. "@every 1h30m", see Every.
+func ParseSchedule(spec string) (SpecSchedule, error) {
+	if s := strings.TrimPrefix(spec, "@every "); s != spec {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("timewheel: invalid schedule spec %q", spec)
+		}
+		return Every(d), nil
+	}
+	return nil, fmt.Errorf("timewheel: unsupported schedule spec %q", spec)
+}
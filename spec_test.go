@@ -0,0 +1,34 @@
+package timewheel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvery(t *testing.T) {
+	sh := Every(time.Minute)
+	now := time.Now()
+	require.Equal(t, now.Add(time.Minute), sh.Next(now))
+	require.Equal(t, "@every 1m0s", sh.Spec())
+
+	require.Panics(t, func() {
+		Every(0)
+	})
+}
+
+func TestParseSchedule(t *testing.T) {
+	sh, err := ParseSchedule("@every 1h30m")
+	require.Nil(t, err)
+	require.Equal(t, Every(time.Hour+time.Minute*30), sh)
+
+	sh, err = ParseSchedule(Every(time.Second).Spec())
+	require.Nil(t, err)
+	require.Equal(t, Every(time.Second), sh)
+
+	for _, spec := range []string{"", "@every", "@every -1s", "@every x", "* * * * *"} {
+		_, err = ParseSchedule(spec)
+		require.NotNil(t, err, spec)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latenessBounds are the upper bounds of the lateness histogram buckets.
+var latenessBounds = []time.Duration{
+	time.Millisecond,
+	time.Millisecond * 2,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 20,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 200,
+	time.Millisecond * 500,
+	time.Second,
+	time.Second * 5,
+	time.Second * 30,
+}
+
+// MetricsSink receives the metrics events of a TimeWheel, it's used to feed the
+// metrics into an external backend. The methods are called synchronously by the
+// TimeWheel, so they must be fast and safe for concurrent use.
+//
+// Only the events are pushed to the sink. The gauges, e.g. the pending timers of
+// each level, the overflow depth, the running jobs and the queue length of Executor,
+// are only available by polling Stats.
+type MetricsSink interface {
+	// IncAdded is called when a timer is added.
+	IncAdded()
+	// IncFired is called when a timer expires and its job is dispatched.
+	IncFired()
+	// IncCancelled is called when a pending timer is closed.
+	IncCancelled()
+	// IncRejected is called when a timer is rejected by the limit or quotas.
+	IncRejected()
+	// ObserveLateness is called with the actual dispatch time minus the expiration
+	// each time a timer fires.
+	ObserveLateness(d time.Duration)
+}
+
+// Histogram is a snapshot of a histogram.
+type Histogram struct {
+	// The upper bounds of buckets.
+	Bounds []time.Duration
+	// The number of observations in each bucket, the extra last one is for the
+	// observations greater than all bounds. Not cumulative.
+	Counts []uint64
+	// The number and sum of all observations.
+	Count uint64
+	Sum   time.Duration
+}
+
+// Stats is a snapshot of the TimeWheel's metrics.
+type Stats struct {
+	Added     uint64 // The total number of added timers.
+	Fired     uint64 // The total number of expirations, each execution of recurring timers counts.
+	Cancelled uint64 // The total number of pending timers that are closed.
+	Rejected  uint64 // The total number of timers rejected by the limit or quotas.
+
+	Pending        int   // The number of pending timers, see TimeWheel.Len.
+	MaxTimers      int   // The limit of pending timers, 0 means no limit, see WithMaxTimers.
+	PendingByLevel []int // The number of timers in the buckets of each level of wheels.
+	OverflowDepth  int   // The number of overflow wheels.
+
+	Running       int // The number of jobs that are running.
+	ExecutorQueue int // The queue length of Executor, 0 if it does not implement Len() int.
+
+	Lateness Histogram // The actual dispatch time minus the expiration of timers.
+}
+
+// metrics holds the counters of a TimeWheel.
+type metrics struct {
+	added     uint64
+	fired     uint64
+	cancelled uint64
+	rejected  uint64
+	running   int64
+
+	latenessCount uint64
+	latenessSum   int64
+	latenessHist  []uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		latenessHist: make([]uint64, len(latenessBounds)+1),
+	}
+}
+
+func (m *metrics) fire(lateness time.Duration) {
+	if lateness < 0 {
+		lateness = 0
+	}
+
+	i := 0
+	for i < len(latenessBounds) && lateness > latenessBounds[i] {
+		i++
+	}
+	atomic.AddUint64(&m.latenessHist[i], 1)
+	atomic.AddUint64(&m.latenessCount, 1)
+	atomic.AddInt64(&m.latenessSum, int64(lateness))
+	atomic.AddUint64(&m.fired, 1)
+}
+
+// Stats returns a snapshot of the TimeWheel's metrics.
+//
+// The PendingByLevel is collected by locking one bucket at a time, thus it's
+// not an atomic view with the other fields.
+func (tw *TimeWheel) Stats() Stats {
+	m := tw.metrics
+	stats := Stats{
+		Added:     atomic.LoadUint64(&m.added),
+		Fired:     atomic.LoadUint64(&m.fired),
+		Cancelled: atomic.LoadUint64(&m.cancelled),
+		Rejected:  atomic.LoadUint64(&m.rejected),
+		Pending:   tw.Len(),
+		MaxTimers: int(tw.maxTimers),
+		Running:   int(atomic.LoadInt64(&m.running)),
+		Lateness: Histogram{
+			Bounds: append([]time.Duration(nil), latenessBounds...),
+			Counts: make([]uint64, len(m.latenessHist)),
+			Count:  atomic.LoadUint64(&m.latenessCount),
+			Sum:    time.Duration(atomic.LoadInt64(&m.latenessSum)),
+		},
+	}
+	for i := range m.latenessHist {
+		stats.Lateness.Counts[i] = atomic.LoadUint64(&m.latenessHist[i])
+	}
+
+	for w := tw; w != nil; w = (*TimeWheel)(atomic.LoadPointer(&w.overflow)) {
+		n := 0
+		for _, b := range w.buckets {
+			n += b.len()
+		}
+		stats.PendingByLevel = append(stats.PendingByLevel, n)
+	}
+	stats.OverflowDepth = len(stats.PendingByLevel) - 1
+
+	if q, ok := tw.executor.(interface{ Len() int }); ok {
+		stats.ExecutorQueue = q.Len()
+	}
+	return stats
+}
@@ -0,0 +1,121 @@
+package timewheel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSink struct {
+	mu        sync.Mutex
+	added     int
+	fired     int
+	cancelled int
+	rejected  int
+	lateness  []time.Duration
+}
+
+func (s *testSink) IncAdded()     { s.mu.Lock(); s.added++; s.mu.Unlock() }
+func (s *testSink) IncFired()     { s.mu.Lock(); s.fired++; s.mu.Unlock() }
+func (s *testSink) IncCancelled() { s.mu.Lock(); s.cancelled++; s.mu.Unlock() }
+func (s *testSink) IncRejected()  { s.mu.Lock(); s.rejected++; s.mu.Unlock() }
+func (s *testSink) ObserveLateness(d time.Duration) {
+	s.mu.Lock()
+	s.lateness = append(s.lateness, d)
+	s.mu.Unlock()
+}
+
+func TestTimeWheel_Stats(t *testing.T) {
+	sink := new(testSink)
+	tw := New(time.Millisecond, 8, WithMetricsSink(sink))
+	tw.Start()
+	defer tw.Stop()
+
+	stats := tw.Stats()
+	require.Equal(t, uint64(0), stats.Added)
+	require.Equal(t, []int{0}, stats.PendingByLevel)
+	require.Equal(t, 0, stats.OverflowDepth)
+	require.Equal(t, latenessBounds, stats.Lateness.Bounds)
+	require.Equal(t, len(latenessBounds)+1, len(stats.Lateness.Counts))
+
+	doneC := make(chan struct{})
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*5), func(ctx context.Context) error {
+		close(doneC)
+		return nil
+	})
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+
+	stats = tw.Stats()
+	require.Equal(t, uint64(2), stats.Added)
+	require.Equal(t, 2, stats.Pending)
+	require.Greater(t, stats.OverflowDepth, 0)
+	require.Equal(t, stats.OverflowDepth+1, len(stats.PendingByLevel))
+	var n int
+	for _, c := range stats.PendingByLevel {
+		n += c
+	}
+	require.Equal(t, 2, n)
+
+	<-doneC
+	timer.Close()
+	// Closes again is not counted.
+	timer.Close()
+
+	stats = tw.Stats()
+	require.Equal(t, uint64(1), stats.Fired)
+	require.Equal(t, uint64(1), stats.Cancelled)
+	require.Equal(t, 0, stats.Pending)
+	require.Equal(t, uint64(1), stats.Lateness.Count)
+	var c uint64
+	for _, v := range stats.Lateness.Counts {
+		c += v
+	}
+	require.Equal(t, uint64(1), c)
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	require.Equal(t, 2, sink.added)
+	require.Equal(t, 1, sink.fired)
+	require.Equal(t, 1, sink.cancelled)
+	require.Equal(t, 1, len(sink.lateness))
+	require.Equal(t, stats.Lateness.Sum, sink.lateness[0])
+}
+
+func TestTimeWheel_Stats_Rejected(t *testing.T) {
+	sink := new(testSink)
+	tw := New(time.Millisecond, 8, WithMetricsSink(sink), WithMaxTimers(1))
+
+	at := time.Now().Add(time.Hour)
+	job := func(ctx context.Context) error { return nil }
+	t1 := tw.TimeFunc(context.Background(), at, job)
+	defer t1.Close()
+	t2 := tw.TimeFunc(context.Background(), at, job)
+	require.NotNil(t, t2.Err())
+
+	stats := tw.Stats()
+	require.Equal(t, uint64(1), stats.Rejected)
+	sink.mu.Lock()
+	require.Equal(t, 1, sink.rejected)
+	sink.mu.Unlock()
+
+	// The bounds of snapshot are copied.
+	stats.Lateness.Bounds[0] = time.Hour
+	require.Equal(t, time.Millisecond, tw.Stats().Lateness.Bounds[0])
+}
+
+func Test_metrics_fire(t *testing.T) {
+	m := newMetrics()
+	m.fire(-time.Millisecond)
+	m.fire(time.Millisecond)
+	m.fire(time.Millisecond * 3)
+	m.fire(time.Hour)
+
+	require.Equal(t, uint64(4), m.fired)
+	require.Equal(t, uint64(2), m.latenessHist[0])
+	require.Equal(t, uint64(1), m.latenessHist[2])
+	require.Equal(t, uint64(1), m.latenessHist[len(latenessBounds)])
+	require.Equal(t, int64(time.Hour+time.Millisecond*4), m.latenessSum)
+}
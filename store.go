@@ -0,0 +1,213 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Record is the serializable form of a timer created by a registered job type.
+type Record struct {
+	ID         uint64   `json:"id"`
+	JobType    string   `json:"job_type"`
+	Payload    []byte   `json:"payload,omitempty"`
+	Expiration int64    `json:"expiration"`     // in milliseconds.
+	Spec       string   `json:"spec,omitempty"` // The spec of SpecSchedule, empty for one-shot timer.
+	Tags       []string `json:"tags,omitempty"`
+	Key        string   `json:"key,omitempty"`  // The key of timer, see Upsert.
+	Name       string   `json:"name,omitempty"` // The name set by WithName.
+}
+
+// Store used to persist the timers, thus they can be recovered after restart.
+type Store interface {
+	// Save adds the record to the store, or replaces the one with the same ID.
+	Save(rec *Record) error
+	// Delete removes the record of id from the store.
+	// It's not an error if the record does not exist.
+	Delete(id uint64) error
+	// LoadAll returns all records in the store.
+	LoadAll() ([]*Record, error)
+}
+
+// MisfirePolicy decides what to do with the recovered timers that have
+// expired while the TimeWheel was not running.
+type MisfirePolicy int
+
+const (
+	// MisfireFireNow runs the misfired timers immediately.
+	MisfireFireNow MisfirePolicy = iota
+	// MisfireDiscard drops the misfired one-shot timers and deletes them from the
+	// store, and skips the missed executions of the recurring timers.
+	MisfireDiscard
+)
+
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireFireNow:
+		return "fire-now"
+	case MisfireDiscard:
+		return "discard"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, it's the same as String.
+func (p MisfirePolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, it accepts the forms of String.
+func (p *MisfirePolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "fire-now":
+		*p = MisfireFireNow
+	case "discard":
+		*p = MisfireDiscard
+	default:
+		return fmt.Errorf("timewheel: unknown misfire policy %q", text)
+	}
+	return nil
+}
+
+// record returns the serializable form of t.
+func (t *Timer) record() *Record {
+	rec := &Record{
+		ID:         t.id,
+		JobType:    t.jobType,
+		Payload:    t.payload,
+		Expiration: t.expiration,
+		Spec:       "",
+		Tags:       t.tags,
+		Key:        t.key,
+		Name:       t.name,
+	}
+	if sh, ok := t.sh.(SpecSchedule); ok {
+		rec.Spec = sh.Spec()
+	}
+	return rec
+}
+
+// persist saves t into the store if required.
+func (tw *TimeWheel) persist(t *Timer) error {
+	if tw.store == nil || t.jobType == "" {
+		return nil
+	}
+
+	tw.storeMu.Lock()
+	defer tw.storeMu.Unlock()
+
+	// Don't resurrect the record of a timer that has left the TimeWheel.
+	if atomic.LoadInt32(&t.finished) == 1 {
+		return nil
+	}
+	if err := tw.store.Save(t.record()); err != nil {
+		return fmt.Errorf("timewheel: save timer %d: %w", t.id, err)
+	}
+	return nil
+}
+
+// Recover loads all records from the Store and reinserts them into the TimeWheel,
+// the misfired ones are handled according to the MisfirePolicy. The records whose
+// job type has not been registered are skipped and kept in the store.
+//
+// Recover only takes effect once, and Start calls it automatically. Calls it before
+// Start if the invoker wants to handle the error.
+func (tw *TimeWheel) Recover() error {
+	if tw.store == nil || !atomic.CompareAndSwapInt32(&tw.recovered, 0, 1) {
+		return nil
+	}
+
+	records, err := tw.store.LoadAll()
+	if err != nil {
+		// Allows to retry.
+		atomic.StoreInt32(&tw.recovered, 0)
+		return fmt.Errorf("timewheel: load timers from store: %w", err)
+	}
+
+	now := timeToMs(time.Now())
+	for _, rec := range records {
+		// Keep the new allocated ids greater than the recovered ones.
+		for last := atomic.LoadUint64(&tw.lastID); rec.ID > last; last = atomic.LoadUint64(&tw.lastID) {
+			if atomic.CompareAndSwapUint64(&tw.lastID, last, rec.ID) {
+				break
+			}
+		}
+
+		timer, err := tw.fromRecord(rec, now)
+		if err != nil {
+			tw.logger.Warn("timewheel: skip recovering timer", "timer_id", rec.ID, "error", err)
+			continue
+		}
+		timer.id = rec.ID
+
+		if timer.finished == 1 {
+			// Discarded by the MisfirePolicy.
+			if err := tw.store.Delete(rec.ID); err != nil {
+				return fmt.Errorf("timewheel: delete timer %d: %w", rec.ID, err)
+			}
+			continue
+		}
+		if timer.expiration != rec.Expiration {
+			if err := tw.persist(timer); err != nil {
+				return err
+			}
+		}
+		tw.track(timer)
+		tw.submitKeyed(timer)
+	}
+	return nil
+}
+
+// fromRecord creates the timer from rec, the misfired timer is handled according
+// to the MisfirePolicy. The returned timer is finished if it's been discarded.
+func (tw *TimeWheel) fromRecord(rec *Record, now int64) (*Timer, error) {
+	job, err := tw.registry.New(rec.JobType, rec.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := tw.newTimer(context.Background(), rec.Expiration, job.Run, nil)
+	timer.jobType = rec.JobType
+	timer.payload = rec.Payload
+	timer.tags = rec.Tags
+	timer.key = rec.Key
+	timer.name = rec.Name
+
+	if rec.Spec != "" {
+		sh, err := ParseSchedule(rec.Spec)
+		if err != nil {
+			return nil, err
+		}
+		timer.sh = sh
+	}
+
+	if rec.Expiration < now {
+		tw.logger.Warn("timewheel: timer misfired", "timer_id", rec.ID,
+			"expiration", msToTime(rec.Expiration), "policy", tw.misfire.String())
+
+		switch {
+		case tw.misfire == MisfireDiscard && timer.sh != nil:
+			// Skip the missed executions.
+			next := timer.sh.Next(msToTime(now).In(tw.location))
+			if next.IsZero() {
+				timer.finished = 1
+				break
+			}
+			timer.expiration = timeToMs(next)
+		case tw.misfire == MisfireDiscard:
+			timer.finished = 1
+		case timer.sh != nil:
+			// Fire once now and calculates the next executions from now
+			// instead of catching up all the missed ones.
+			timer.expiration = now
+		}
+	}
+	return timer, nil
+}
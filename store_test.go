@@ -0,0 +1,126 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileStore(t *testing.T) *FileStore {
+	s, err := OpenFileStore(filepath.Join(t.TempDir(), "timers.log"))
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestTimeWheel_Recover(t *testing.T) {
+	store := newTestFileStore(t)
+	now := timeToMs(time.Now())
+
+	require.Nil(t, store.Save(&Record{ID: 7, JobType: "echo", Payload: []byte("misfired"), Expiration: now - 1000}))
+	require.Nil(t, store.Save(&Record{ID: 9, JobType: "echo", Payload: []byte("pending"), Expiration: now + 20}))
+	require.Nil(t, store.Save(&Record{ID: 11, JobType: "unknown", Expiration: now + 20}))
+
+	retC := make(chan string, 2)
+	tw := Default(WithStore(store))
+	tw.RegisterJob("echo", func(payload []byte) (Job, error) {
+		return JobFunc(func(ctx context.Context) error {
+			retC <- string(payload)
+			return nil
+		}), nil
+	})
+	tw.Start()
+	defer tw.Stop()
+
+	require.Equal(t, "misfired", <-retC)
+	require.Equal(t, "pending", <-retC)
+
+	// The new allocated ids are greater than the recovered ones.
+	timer, err := tw.TimeNamed(context.Background(), time.Now().Add(time.Hour), "echo", nil)
+	require.Nil(t, err)
+	require.Greater(t, timer.ID(), uint64(11))
+	timer.Close()
+
+	require.Eventually(t, func() bool {
+		records, err := store.LoadAll()
+		require.Nil(t, err)
+		return len(records) == 1 && records[0].ID == 11
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestTimeWheel_Recover_MisfireDiscard(t *testing.T) {
+	store := newTestFileStore(t)
+	require.Nil(t, store.Save(&Record{ID: 1, JobType: "echo", Expiration: timeToMs(time.Now()) - 1000}))
+
+	var called bool
+	tw := Default(WithStore(store), WithMisfirePolicy(MisfireDiscard))
+	tw.RegisterJob("echo", func(payload []byte) (Job, error) {
+		return JobFunc(func(ctx context.Context) error {
+			called = true
+			return nil
+		}), nil
+	})
+	require.Nil(t, tw.Recover())
+	require.False(t, called)
+
+	records, err := store.LoadAll()
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+}
+
+func TestTimeWheel_Recover_Error(t *testing.T) {
+	store := newTestFileStore(t)
+	require.Nil(t, store.Close())
+
+	logger := new(testLogger)
+	tw := Default(WithStore(store), WithLogger(logger))
+	require.Equal(t, ErrStoreClosed, errors.Unwrap(tw.Recover()))
+
+	// Start keeps running without the stored timers.
+	require.NotPanics(t, func() {
+		tw.Start()
+	})
+	defer tw.Stop()
+	require.True(t, logger.has("ERROR timewheel: recover timers failed"))
+}
+
+func TestTimeWheel_Recover_Schedule(t *testing.T) {
+	for _, policy := range []MisfirePolicy{MisfireFireNow, MisfireDiscard} {
+		store := newTestFileStore(t)
+		now := timeToMs(time.Now())
+		require.Nil(t, store.Save(&Record{ID: 1, JobType: "echo", Expiration: now - 60000, Spec: "@every 1h"}))
+
+		retC := make(chan struct{}, 1)
+		tw := Default(WithStore(store), WithMisfirePolicy(policy))
+		tw.Registry().RegisterFunc("echo", func(ctx context.Context, payload []byte) error {
+			retC <- struct{}{}
+			return nil
+		})
+		tw.Start()
+
+		var fired bool
+		select {
+		case <-retC:
+			fired = true
+		case <-time.After(time.Millisecond * 100):
+		}
+		tw.Stop()
+		require.Equal(t, policy == MisfireFireNow, fired)
+
+		// The next execution is calculated from now.
+		records, err := store.LoadAll()
+		require.Nil(t, err)
+		require.Equal(t, 1, len(records))
+		require.GreaterOrEqual(t, records[0].Expiration, now+3600000)
+	}
+}
+
+func TestMisfirePolicy_String(t *testing.T) {
+	require.Equal(t, "fire-now", MisfireFireNow.String())
+	require.Equal(t, "discard", MisfireDiscard.String())
+	require.Equal(t, "unknown", MisfirePolicy(-1).String())
+}
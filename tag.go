@@ -0,0 +1,99 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import "sync/atomic"
+
+// track counts t as a pending timer, indexes t by its tags and adds t to its group,
+// it's called before t is submitted the first time. If the group has been closed,
+// t is closed immediately.
+func (tw *TimeWheel) track(t *Timer) {
+	t.pending = 1
+	atomic.AddInt64(&tw.pending, 1)
+	tw.pendTags(t, 1)
+	atomic.AddUint64(&tw.metrics.added, 1)
+	if tw.sink != nil {
+		tw.sink.IncAdded()
+	}
+	for _, o := range tw.observers {
+		o.OnAdd(t)
+	}
+
+	if len(t.tags) != 0 {
+		tw.tagsMu.Lock()
+		if tw.tags == nil {
+			tw.tags = make(map[string]map[*Timer]struct{})
+		}
+		for _, tag := range t.tags {
+			set, ok := tw.tags[tag]
+			if !ok {
+				set = make(map[*Timer]struct{})
+				tw.tags[tag] = set
+			}
+			set[t] = struct{}{}
+		}
+		tw.tagsMu.Unlock()
+	}
+
+	if t.group != nil && !t.group.add(t) {
+		t.Close()
+	}
+}
+
+// untrack removes t from the tag index and its group.
+func (tw *TimeWheel) untrack(t *Timer) {
+	if len(t.tags) != 0 {
+		tw.tagsMu.Lock()
+		for _, tag := range t.tags {
+			if set, ok := tw.tags[tag]; ok {
+				delete(set, t)
+				if len(set) == 0 {
+					delete(tw.tags, tag)
+				}
+			}
+		}
+		tw.tagsMu.Unlock()
+	}
+
+	if t.group != nil {
+		t.group.remove(t)
+	}
+}
+
+// CountByTag returns the number of timers with tag that have not left the TimeWheel,
+// including the one-shot timers whose jobs are running.
+func (tw *TimeWheel) CountByTag(tag string) int {
+	tw.tagsMu.Lock()
+	n := len(tw.tags[tag])
+	tw.tagsMu.Unlock()
+	return n
+}
+
+// CancelByTag closes all pending timers with tag, and returns the number of them.
+func (tw *TimeWheel) CancelByTag(tag string) int {
+	tw.tagsMu.Lock()
+	set := tw.tags[tag]
+	timers := make([]*Timer, 0, len(set))
+	for t := range set {
+		timers = append(timers, t)
+	}
+	tw.tagsMu.Unlock()
+
+	for _, t := range timers {
+		t.Close()
+	}
+	return len(timers)
+}
+
+// hasTag reports whether t has tag.
+func hasTag(t *Timer, tag string) bool {
+	for _, v := range t.tags {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
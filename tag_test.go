@@ -0,0 +1,57 @@
+package timewheel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWheel_CancelByTag(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	fn := JobFunc(func(ctx context.Context) error { return nil })
+	at := time.Now().Add(time.Hour)
+
+	t1 := tw.TimeFunc(context.Background(), at, fn, WithTags("tenant-1", "conn-1"))
+	t2 := tw.TimeFunc(context.Background(), at, fn, WithTags("tenant-1"))
+	t3 := tw.ScheduleJob(context.Background(), Every(time.Hour), fn, WithTags("tenant-2"))
+	require.Equal(t, []string{"tenant-1", "conn-1"}, t1.Tags())
+
+	require.Equal(t, 2, tw.CountByTag("tenant-1"))
+	require.Equal(t, 1, tw.CountByTag("conn-1"))
+	require.Equal(t, 1, tw.CountByTag("tenant-2"))
+	require.Equal(t, 0, tw.CountByTag("tenant-3"))
+
+	require.Equal(t, 2, tw.CancelByTag("tenant-1"))
+	require.NotNil(t, t1.ctxCancel.Err())
+	require.NotNil(t, t2.ctxCancel.Err())
+	require.Nil(t, t3.ctxCancel.Err())
+	require.Equal(t, 0, tw.CountByTag("tenant-1"))
+	require.Equal(t, 0, tw.CountByTag("conn-1"))
+	require.Equal(t, 0, tw.CancelByTag("tenant-1"))
+
+	t3.Close()
+	require.Equal(t, 0, tw.CountByTag("tenant-2"))
+}
+
+func TestTimeWheel_Tags_Expired(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	doneC := make(chan struct{})
+	tw.TimeFunc(context.Background(), time.Now().Add(time.Millisecond*10), func(ctx context.Context) error {
+		close(doneC)
+		return nil
+	}, WithTags("tag"))
+	require.Equal(t, 1, tw.CountByTag("tag"))
+
+	<-doneC
+	require.Eventually(t, func() bool {
+		return tw.CountByTag("tag") == 0
+	}, time.Second, time.Millisecond*10)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the error reported to the Logger and Observers when a run of job
+// exceeds its timeout, see WithJobTimeout.
+type TimeoutError struct {
+	Timeout time.Duration // The timeout of the run.
+	Elapsed time.Duration // The actual duration of the run.
+	Err     error         // The error returned by the job, may be nil.
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("timewheel: job timed out after %v (timeout %v): %v", e.Elapsed, e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("timewheel: job timed out after %v (timeout %v)", e.Elapsed, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// HangObserver is an optional interface that can be implemented by an Observer
+// to be notified of the jobs that ignore the cancellation of timeout.
+type HangObserver interface {
+	// OnJobHang is called when a run of job is still running after its timeout
+	// plus the grace period set by WithHangGrace, elapsed is the time since
+	// the run started.
+	OnJobHang(t *Timer, elapsed time.Duration)
+}
+
+// timeout returns the timeout of t's job runs, 0 means no timeout.
+func (tw *TimeWheel) timeout(t *Timer) time.Duration {
+	switch {
+	case t.timeout > 0:
+		return t.timeout
+	case t.timeout < 0:
+		return 0
+	default:
+		return tw.jobTimeout
+	}
+}
+
+// withTimeout derives the context of a run of t's job that started at start. The
+// returned done func must be called after the run returns, it returns err wrapped
+// in *TimeoutError if the run exceeds the timeout.
+func (tw *TimeWheel) withTimeout(ctx context.Context, t *Timer, start time.Time) (context.Context, func(err error) error) {
+	timeout := tw.timeout(t)
+	if timeout <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	var hang *time.Timer
+	if tw.hangGrace > 0 {
+		hang = time.AfterFunc(timeout+tw.hangGrace, func() {
+			elapsed := time.Since(start)
+			tw.logger.Warn("timewheel: job ignores cancellation", "timer_id", t.id,
+				"timeout", timeout, "elapsed", elapsed)
+			for _, o := range tw.observers {
+				if ho, ok := o.(HangObserver); ok {
+					ho.OnJobHang(t, elapsed)
+				}
+			}
+		})
+	}
+
+	return ctx, func(err error) error {
+		if hang != nil {
+			hang.Stop()
+		}
+		cancel()
+
+		if _, ok := err.(*PanicError); ok {
+			return err
+		}
+		if elapsed := time.Since(start); elapsed >= timeout {
+			return &TimeoutError{Timeout: timeout, Elapsed: elapsed, Err: err}
+		}
+		return err
+	}
+}
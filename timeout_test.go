@@ -0,0 +1,109 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hangObserver struct {
+	NopObserver
+
+	mu   sync.Mutex
+	hung []uint64
+}
+
+func (o *hangObserver) OnJobHang(t *Timer, elapsed time.Duration) {
+	o.mu.Lock()
+	o.hung = append(o.hung, t.ID())
+	o.mu.Unlock()
+}
+
+func (o *hangObserver) get() []uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]uint64(nil), o.hung...)
+}
+
+func TestWithJobTimeout(t *testing.T) {
+	tw := Default(WithJobTimeout(time.Millisecond * 20))
+	tw.Start()
+	defer tw.Stop()
+
+	job := JobFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	t1 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), job)
+	defer t1.Close()
+
+	err := t1.Trigger(context.Background())
+	var te *TimeoutError
+	require.True(t, errors.As(err, &te))
+	require.Equal(t, time.Millisecond*20, te.Timeout)
+	require.GreaterOrEqual(t, te.Elapsed, te.Timeout)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Equal(t, err, t1.Stats().LastError)
+
+	// The timer's timeout overrides the wheel's.
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), job, WithTimeout(time.Millisecond*5))
+	defer t2.Close()
+	err = t2.Trigger(context.Background())
+	require.True(t, errors.As(err, &te))
+	require.Equal(t, time.Millisecond*5, te.Timeout)
+
+	// The job returns in time.
+	t3 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		require.True(t, ok)
+		return nil
+	})
+	defer t3.Close()
+	require.NoError(t, t3.Trigger(context.Background()))
+
+	// The timeout is disabled for the timer.
+	t4 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+		return nil
+	}, WithTimeout(-1))
+	defer t4.Close()
+	require.NoError(t, t4.Trigger(context.Background()))
+}
+
+func TestWithHangGrace(t *testing.T) {
+	o := new(hangObserver)
+	logger := new(testLogger)
+	tw := Default(WithJobTimeout(time.Millisecond*5), WithHangGrace(time.Millisecond*10),
+		WithObserver(o), WithLogger(logger))
+	tw.Start()
+	defer tw.Stop()
+
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		// Ignores the cancellation.
+		time.Sleep(time.Millisecond * 50)
+		return nil
+	})
+	defer timer.Close()
+
+	err := timer.Trigger(context.Background())
+	var te *TimeoutError
+	require.True(t, errors.As(err, &te))
+	require.Nil(t, te.Err)
+	require.Equal(t, []uint64{timer.ID()}, o.get())
+	require.True(t, logger.has("WARN timewheel: job ignores cancellation"))
+
+	// Not reported if the job returns within the grace period.
+	t2 := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	defer t2.Close()
+	_ = t2.Trigger(context.Background())
+	time.Sleep(time.Millisecond * 20)
+	require.Equal(t, []uint64{timer.ID()}, o.get())
+}
@@ -8,7 +8,9 @@ package timewheel
 import (
 	"container/list"
 	"context"
+	"sync"
 	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -33,6 +35,75 @@ type Timer struct {
 
 	// The timer's Element in list.
 	element *list.Element
+
+	// The TimeWheel that created the timer. It is nil for timers
+	// that not created by TimeFunc or ScheduleJob.
+	tw *TimeWheel
+
+	// The unique id of the timer in its TimeWheel.
+	id uint64
+
+	// The name of the timer set by WithName.
+	name string
+
+	// The creation time in milliseconds.
+	created int64
+
+	// The execution plan of timer created by ScheduleJob, nil for one-shot timer.
+	sh Schedule
+
+	// The job type name and payload for timer created by a registered job type.
+	jobType string
+	payload []byte
+
+	// The unique key of the timer created by TimeWheel.Upsert.
+	key string
+
+	// The tags and group of the timer, set by TimerOption.
+	tags  []string
+	group *Group
+
+	// The lane of the timer set by WithLane, see LaneExecutor.
+	lane string
+
+	// The priority of the timer set by WithPriority, see PriorityExecutor.
+	priority int
+
+	// The overlap policy set by WithOverlapPolicy, and the number of running jobs.
+	overlap OverlapPolicy
+	running int32
+
+	// The timeout of each run of job set by WithTimeout.
+	timeout time.Duration
+
+	// The retry policy set by WithRetry, the number of failed runs of the current
+	// expiration, whether the timer is submitted for retry, and the next cycle to
+	// go back after retry in milliseconds. The cycleMu guards the expiration and
+	// retry state that are changed by the runs of job, since the runs of different
+	// cycles may overlap.
+	retry     *RetryPolicy
+	attempt   int32
+	retrying  int32
+	nextCycle int64
+	cycleMu   sync.Mutex
+
+	// The statistics of the job runs.
+	stats timerStats
+
+	// The future of the timer, resolved is set to 1 while resolving and 2 after
+	// the err is set and the done is closed.
+	done     chan struct{}
+	err      error
+	resolved int32
+
+	// closed is set to 1 when the timer is closed.
+	closed int32
+
+	// pending is set to 1 while the timer is counted by TimeWheel.Len.
+	pending int32
+
+	// finished is set to 1 once the timer has left the TimeWheel forever.
+	finished int32
 }
 
 func (t *Timer) getBucket() *bucket {
@@ -43,6 +114,46 @@ func (t *Timer) setBucket(b *bucket) {
 	atomic.StorePointer(&t.b, unsafe.Pointer(b))
 }
 
+// ID returns the unique id of the timer in its TimeWheel.
+func (t *Timer) ID() uint64 {
+	return t.id
+}
+
+// Name returns the name of the timer. It defaults to the job type name for the named
+// timers, the key for the keyed timers, and "timer" for others if not set by WithName.
+func (t *Timer) Name() string {
+	switch {
+	case t.name != "":
+		return t.name
+	case t.jobType != "":
+		return t.jobType
+	case t.key != "":
+		return t.key
+	default:
+		return "timer"
+	}
+}
+
+// Key returns the key of the timer created by TimeWheel.Upsert, empty for others.
+func (t *Timer) Key() string {
+	return t.key
+}
+
+// Lane returns the lane of the timer set by WithLane.
+func (t *Timer) Lane() string {
+	return t.lane
+}
+
+// Priority returns the priority of the timer set by WithPriority.
+func (t *Timer) Priority() int {
+	return t.priority
+}
+
+// Tags returns the tags of the timer.
+func (t *Timer) Tags() []string {
+	return t.tags
+}
+
 // Close prevents the Timer from firing.
 //
 // The func will be blocked until the timer has finally been removed from the TimeWheel.
@@ -50,6 +161,17 @@ func (t *Timer) setBucket(b *bucket) {
 // goroutine; Close does not wait for t.jobFunc to complete before returning. If the invoker
 // needs to know whether t.jobFunc is completed, it must coordinate with t.jobFunc explicitly.
 func (t *Timer) Close() {
+	t.stop()
+
+	if t.tw != nil {
+		t.tw.cancel(t)
+	}
+}
+
+// stop removes t from the TimeWheel and cancels its context.
+func (t *Timer) stop() {
+	atomic.StoreInt32(&t.closed, 1)
+
 	for b := t.getBucket(); b != nil; b = t.getBucket() {
 		// The b.delete may fail if t's bucket has changed due to TimeWheel call the b.flush.
 		// Thus, we re-get t's possibly new bucket and retry until the bucket becomes nil or
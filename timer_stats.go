@@ -0,0 +1,70 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"sync"
+	"time"
+)
+
+// TimerStats is a snapshot of the statistics of a timer's job runs.
+type TimerStats struct {
+	Runs      uint64 // The number of completed runs, including the triggered ones.
+	Failures  uint64 // The number of runs that returned an error or panicked.
+	Skipped   uint64 // The number of runs skipped by the OverlapPolicy.
+	Triggered uint64 // The number of runs started by Timer.Trigger.
+	Retries   uint64 // The number of retries scheduled by the RetryPolicy.
+
+	LastStart    time.Time     // The start time of the last completed run, zero if none.
+	LastDuration time.Duration // The duration of the last completed run.
+	LastError    error         // The error of the last completed run.
+}
+
+// timerStats collects the TimerStats of a timer.
+type timerStats struct {
+	mu      sync.Mutex
+	s       TimerStats
+	history history
+}
+
+// record records a completed run.
+func (ts *timerStats) record(run Run) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.s.Runs++
+	if run.Err != nil {
+		ts.s.Failures++
+	}
+	if run.Triggered {
+		ts.s.Triggered++
+	}
+	ts.s.LastStart = run.Start
+	ts.s.LastDuration = run.Duration
+	ts.s.LastError = run.Err
+	ts.history.add(run)
+}
+
+// skip records a skipped run.
+func (ts *timerStats) skip() {
+	ts.mu.Lock()
+	ts.s.Skipped++
+	ts.mu.Unlock()
+}
+
+// retry records a scheduled retry.
+func (ts *timerStats) retry() {
+	ts.mu.Lock()
+	ts.s.Retries++
+	ts.mu.Unlock()
+}
+
+// Stats returns a snapshot of the statistics of t's job runs.
+func (t *Timer) Stats() TimerStats {
+	t.stats.mu.Lock()
+	defer t.stats.mu.Unlock()
+	return t.stats.s
+}
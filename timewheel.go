@@ -6,6 +6,9 @@
 package timewheel
 
 import (
+	"context"
+	"runtime/debug"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -25,6 +28,10 @@ type TimeWheel struct {
 	span    int64 // The time span of each layer, in milliseconds(nanoseconds/time.Millisecond).
 	current int64 // The current time of time wheel, in milliseconds(nanoseconds/time.Millisecond).
 
+	// The 64-bit atomic fields are placed at top to keep them aligned on 32-bit platforms.
+	lastID  uint64 // The last allocated timer id.
+	pending int64  // The number of pending timers.
+
 	buckets []*bucket
 	queue   *dqueue.DQueue
 
@@ -34,6 +41,74 @@ type TimeWheel struct {
 	// Store the options.
 	opts []Option
 
+	// The registry of job types for the named timers.
+	registry *Registry
+
+	// The store for persisting the named timers, nil means disabled.
+	store     Store
+	storeMu   sync.Mutex
+	misfire   MisfirePolicy
+	recovered int32
+
+	// The keyed timers, see Upsert.
+	keys   map[string]*Timer
+	keysMu sync.Mutex
+
+	// The tagged timers, key is the tag.
+	tags   map[string]map[*Timer]struct{}
+	tagsMu sync.Mutex
+
+	// The executor for running the jobs of expired timers.
+	executor Executor
+
+	// The built-in metrics, and the optional sink for reporting them.
+	metrics *metrics
+	sink    MetricsSink
+
+	// The observers of timer lifecycle.
+	observers []Observer
+
+	// The logger, default discards all logs.
+	logger Logger
+
+	// The tracer that wraps each run of jobs, nil means disabled.
+	tracer Tracer
+
+	// The max number of runs kept in the history of each timer.
+	historySize int
+
+	// The default timeout of each run of jobs, and the grace period after it
+	// before reporting the job that ignores the cancellation, 0 means disabled.
+	jobTimeout time.Duration
+	hangGrace  time.Duration
+
+	// The expired timers that are held while the TimeWheel is paused.
+	paused  int32
+	held    []*Timer
+	pauseMu sync.Mutex
+
+	// The handler of expired timers in batch mode, nil means disabled.
+	batch func(expired []*Timer)
+
+	// The queue of buckets in manual mode, nil means the buckets are processed
+	// by the goroutine of queue. See WithManualAdvance.
+	manual *bucketQueue
+
+	// The max number of pending timers, 0 means no limit. The admitMu makes the
+	// check and track of new timers atomic, and the freeC is closed when a pending
+	// timer is released, see WaitTimeFunc.
+	maxTimers int64
+	admitMu   sync.Mutex
+	freeC     chan struct{}
+	freeMu    sync.Mutex
+
+	// The quotas of tags, see WithTagQuota.
+	quotas  map[string]*quotaState
+	quotaMu sync.Mutex
+
+	// The level of the wheel in the hierarchy, 0 for the lowest.
+	level int
+
 	// The higher-level overflow TimeWheel.
 	//
 	// NOTICE: This field may be updated and read concurrently, through tw.add().
@@ -46,7 +121,8 @@ func Default(opts ...Option) *TimeWheel {
 }
 
 // New creates an TimeWheel with the given tick and wheel size.
-// The value of tick must >= 1ms, the size must >= 1.
+// The value of tick must >= 1ms, the size must >= 1, otherwise it panics.
+// See NewWithConfig for creating by user-supplied configuration.
 func New(tick time.Duration, size int64, opts ...Option) *TimeWheel {
 	if tick < time.Millisecond {
 		panic("timewheel: tick must be greater than or equal to 1ms")
@@ -75,6 +151,10 @@ func newTimeWheel(tickMs int64, size int64, startMs int64, queue *dqueue.DQueue,
 		location: time.Local,
 		opts:     opts,
 		overflow: nil,
+		registry: NewRegistry(),
+		executor: goExecutor{},
+		metrics:  newMetrics(),
+		logger:   nopLogger{},
 	}
 	for _, opt := range opts {
 		opt(tw)
@@ -84,8 +164,21 @@ func newTimeWheel(tickMs int64, size int64, startMs int64, queue *dqueue.DQueue,
 
 // Start starts the current time wheel in a goroutine.
 // You can call the Wait method to blocks the main process after.
+//
+// If a Store is set and Recover has not been called yet, Start recovers the
+// timers from the store first. If the recovery fails, e.g. a transient I/O error,
+// the error is logged and the TimeWheel keeps running without the stored timers,
+// Recover can be called again to retry.
+//
+// In manual mode, no goroutine is started, see Advance.
 func (tw *TimeWheel) Start() {
-	tw.queue.Start(tw.process)
+	if err := tw.Recover(); err != nil {
+		tw.logger.Error("timewheel: recover timers failed", "error", err)
+	}
+	if tw.manual == nil {
+		tw.queue.Start(tw.process)
+	}
+	tw.logger.Info("timewheel: started", "tick", time.Duration(tw.tick)*time.Millisecond, "size", tw.size)
 }
 
 // Stop stops the current time wheel.
@@ -94,15 +187,49 @@ func (tw *TimeWheel) Start() {
 // not wait for the jobFunc to complete before returning. If the invoker needs to
 // know whether the jobFunc is completed, it must coordinate with the jobFunc explicitly.
 func (tw *TimeWheel) Stop() {
-	tw.queue.Stop()
+	if tw.manual == nil {
+		tw.queue.Stop()
+	}
+	tw.logger.Info("timewheel: stopped", "pending", tw.Len())
 }
 
 // process the expiration's bucket
 func (tw *TimeWheel) process(val dqueue.Value) {
 	b := val.(*bucket)
 	tw.advance(b.getExpiration())
+	tw.flush(b)
+}
+
+// flush resubmits all timers in the bucket b, returns the dispatched timers. In batch
+// mode, the expired timers are dispatched as a batch.
+//
+// The expired timers are dispatched after b is flushed, since their jobs may run
+// synchronously, e.g. by InlineExecutor, and close the timers in b, which waits for
+// the flush to complete.
+func (tw *TimeWheel) flush(b *bucket) []*Timer {
+	var expired []*Timer
+	b.flush(func(t *Timer) {
+		if atomic.LoadInt32(&t.closed) == 1 {
+			// The timer has been closed while it's being resubmitted.
+			return
+		}
+		if !tw.add(t) {
+			expired = append(expired, t)
+		}
+	})
+	if len(expired) == 0 {
+		return nil
+	}
 
-	b.flush(tw.submit)
+	if tw.batch != nil {
+		// Dispatch a copy, since the batch is filtered in place.
+		tw.dispatchBatch(append([]*Timer(nil), expired...))
+		return expired
+	}
+	for _, t := range expired {
+		tw.dispatch(t)
+	}
+	return expired
 }
 
 // advance push the clock forward.
@@ -121,17 +248,221 @@ func (tw *TimeWheel) advance(expiration int64) {
 }
 
 // submit inserts the timer t into the current timing wheel, or run the
-// timer's jobFunc if it has been expired.
-func (tw *TimeWheel) submit(t *Timer) {
+// timer's jobFunc if it has been expired. It returns true if t is dispatched.
+func (tw *TimeWheel) submit(t *Timer) bool {
+	if atomic.LoadInt32(&t.closed) == 1 {
+		// The timer has been closed while it's being resubmitted.
+		return false
+	}
 	if !tw.add(t) {
-		// Actually execute the jobFunc func.
-		//
-		// Like the standard time.AfterFunc (https://golang.org/pkg/time/#AfterFunc),
-		// always execute the timer's jobFunc in its own goroutine.
-		go func() {
-			_ = t.jobFunc(t.ctxCancel)
-		}()
+		tw.dispatch(t)
+		return true
+	}
+	return false
+}
+
+// dispatch hands the expired timer t to the Executor to run its job, or to the
+// batch handler as a batch of one in batch mode.
+func (tw *TimeWheel) dispatch(t *Timer) {
+	if tw.batch != nil {
+		tw.dispatchBatch([]*Timer{t})
+		return
+	}
+	if !tw.expire(t) {
+		return
+	}
+	// The quotas are kept by the TimeWheel that t belongs to, not the overflow one.
+	t.tw.execute(t)
+}
+
+// expire counts the expiration of t before it's dispatched. It returns false if
+// the TimeWheel is paused, then t is held until Resume.
+func (tw *TimeWheel) expire(t *Timer) bool {
+	if tw.hold(t) {
+		return false
+	}
+	if t.sh == nil {
+		// The one-shot timer is no longer pending once it expired.
+		tw.unpend(t)
+	}
+
+	lateness := time.Duration(timeToMs(time.Now())-t.expiration) * time.Millisecond
+	tw.metrics.fire(lateness)
+	if tw.sink != nil {
+		tw.sink.IncFired()
+		tw.sink.ObserveLateness(lateness)
+	}
+	for _, o := range tw.observers {
+		o.OnExpire(t, lateness)
+	}
+	return true
+}
+
+// run actually executes the jobFunc of t.
+func (tw *TimeWheel) run(t *Timer) {
+	if atomic.LoadInt32(&t.closed) == 1 {
+		// The timer has been closed before its job starts.
+		t.resolve(ErrTimerClosed)
+		return
+	}
+
+	t.cycleMu.Lock()
+	expiration := t.expiration
+	last := true
+	if atomic.SwapInt32(&t.retrying, 0) == 1 {
+		// Go back to the next cycle after retry.
+		last = !tw.resumeCycle(t)
+	} else {
+		// A new expiration resets the attempts of retry.
+		atomic.StoreInt32(&t.attempt, 0)
+		if t.sh != nil {
+			// Schedule the job to execute at the next time if possible.
+			last = !tw.nextCycle(t)
+		}
+	}
+	t.cycleMu.Unlock()
+	if !last {
+		// Submit out of cycleMu, the next cycle may be dispatched and run inline.
+		tw.submit(t)
+	}
+
+	err := tw.exec(t, expiration, false)
+	if err != nil && tw.retry(t, err, last) {
+		return
+	}
+
+	if last {
+		// The timer leaves the TimeWheel after its last job is completed.
+		tw.finish(t)
+		t.resolve(err)
+	}
+}
+
+// exec runs t's job that is scheduled at expiration (in milliseconds), with the overlap
+// policy, observers, tracer, logger and metrics. The triggered reports whether the run
+// is started by Timer.Trigger.
+func (tw *TimeWheel) exec(t *Timer, expiration int64, triggered bool) error {
+	if !t.acquire() {
+		t.stats.skip()
+		tw.logger.Debug("timewheel: job skipped due to overlap", "timer_id", t.id)
+		return ErrOverlap
+	}
+	defer t.release()
+
+	for _, o := range tw.observers {
+		o.OnJobStart(t)
+	}
+
+	ctx := t.ctxCancel
+	var endSpan func(err error)
+	if tw.tracer != nil {
+		ctx, endSpan = tw.tracer.Start(ctx, t.spanInfo(expiration, tw.location))
+	}
+
+	atomic.AddInt64(&tw.metrics.running, 1)
+	start := time.Now()
+	ctx, done := tw.withTimeout(ctx, t, start)
+	err := done(tw.call(ctx, t.jobFunc))
+	elapsed := time.Since(start)
+	atomic.AddInt64(&tw.metrics.running, -1)
+
+	_, panicked := err.(*PanicError)
+	t.stats.record(Run{
+		Scheduled: msToTime(expiration).In(tw.location),
+		Start:     start,
+		Duration:  elapsed,
+		Err:       err,
+		Panicked:  panicked,
+		Triggered: triggered,
+	})
+
+	if endSpan != nil {
+		endSpan(err)
+	}
+
+	if pe, ok := err.(*PanicError); ok {
+		tw.logger.Error("timewheel: job panic", "timer_id", t.id, "panic", pe.Value, "stack", string(pe.Stack))
+	} else if err != nil {
+		tw.logger.Error("timewheel: job failed", "timer_id", t.id, "error", err, "elapsed", elapsed)
+	}
+
+	for _, o := range tw.observers {
+		o.OnJobEnd(t, err, elapsed)
+	}
+	return err
+}
+
+// call calls fn with ctx, the panic is recovered and returned as *PanicError.
+func (tw *TimeWheel) call(ctx context.Context, fn JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx)
+}
+
+// cancel finishes t that is closed by the invoker.
+func (tw *TimeWheel) cancel(t *Timer) {
+	pending := tw.unpend(t)
+	if pending {
+		atomic.AddUint64(&tw.metrics.cancelled, 1)
+		if tw.sink != nil {
+			tw.sink.IncCancelled()
+		}
+		for _, o := range tw.observers {
+			o.OnCancel(t)
+		}
+	}
+	tw.finish(t)
+
+	if pending {
+		// The last job run has not started yet.
+		t.resolve(ErrTimerClosed)
+	}
+}
+
+// finish marks the timer t has left the TimeWheel forever, i.e. it has been closed
+// or its last execution has completed. It's safe to call finish multiple times.
+func (tw *TimeWheel) finish(t *Timer) {
+	if !atomic.CompareAndSwapInt32(&t.finished, 0, 1) {
+		return
+	}
+	if tw.store != nil && t.jobType != "" {
+		tw.storeMu.Lock()
+		if err := tw.store.Delete(t.id); err != nil {
+			tw.logger.Error("timewheel: delete timer from store failed", "timer_id", t.id, "error", err)
+		}
+		tw.storeMu.Unlock()
+	}
+	if t.key != "" {
+		tw.unkey(t)
+	}
+	tw.untrack(t)
+	tw.unpend(t)
+}
+
+// pend counts t as a pending timer again, returns false if t is already pending.
+func (tw *TimeWheel) pend(t *Timer) bool {
+	if atomic.CompareAndSwapInt32(&t.pending, 0, 1) {
+		atomic.AddInt64(&tw.pending, 1)
+		tw.pendTags(t, 1)
+		return true
+	}
+	return false
+}
+
+// unpend stops counting t as a pending timer, returns false if t is not pending.
+func (tw *TimeWheel) unpend(t *Timer) bool {
+	if atomic.CompareAndSwapInt32(&t.pending, 1, 0) {
+		atomic.AddInt64(&tw.pending, -1)
+		tw.pendTags(t, -1)
+		if tw.maxTimers > 0 {
+			tw.free()
+		}
+		return true
 	}
+	return false
 }
 
 // add inserts the timer t into the current timing wheel.
@@ -155,7 +486,11 @@ func (tw *TimeWheel) add(t *Timer) bool {
 			// Any further calls to set the expiration within the same wheel cycle will
 			// pass in the same value and hence return false, thus the bucket with the
 			// same expiration will not be enqueued multiple times.
-			tw.queue.Offer(b.getExpiration(), b)
+			if tw.manual != nil {
+				tw.manual.offer(b.getExpiration(), b)
+			} else {
+				tw.queue.Offer(b.getExpiration(), b)
+			}
 		}
 		return true
 	} else {
@@ -166,7 +501,15 @@ func (tw *TimeWheel) add(t *Timer) bool {
 		if overflow == nil {
 			// Creates and save overflow TimeWheel.
 			ntw := newTimeWheel(tw.span, tw.size, current, tw.queue, tw.opts...)
-			atomic.CompareAndSwapPointer(&tw.overflow, nil, unsafe.Pointer(ntw))
+			ntw.level = tw.level + 1
+			ntw.manual = tw.manual
+			if atomic.CompareAndSwapPointer(&tw.overflow, nil, unsafe.Pointer(ntw)) {
+				tw.logger.Debug("timewheel: overflow wheel created",
+					"level", ntw.level, "span", time.Duration(ntw.span)*time.Millisecond)
+				for _, o := range tw.observers {
+					o.OnOverflowCreated(ntw.level, time.Duration(ntw.span)*time.Millisecond)
+				}
+			}
 
 			// Load safe to avoid concurrent operations.
 			overflow = atomic.LoadPointer(&tw.overflow)
@@ -2,6 +2,7 @@ package timewheel
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -73,3 +74,18 @@ func BenchmarkTimer_StartClose(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkWheel_Add(b *testing.B) {
+	w := NewWheel(time.Millisecond, 64, func(expired []int) {})
+	now := time.Now()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Add(keys[i%len(keys)], i, now.Add(time.Duration(i%3600)*time.Second))
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"time"
+)
+
+// SpanInfo describes a run of a timer's job for Tracer.
+type SpanInfo struct {
+	Name        string    // The span name, see Timer.Name.
+	TimerID     uint64    // The id of timer.
+	ScheduledAt time.Time // The time when the timer was created.
+	Expiration  time.Time // The expiration of this run.
+	Recurring   bool      // Whether the timer is created by ScheduleJob or ScheduleNamed.
+}
+
+// Tracer wraps each run of jobs with a span, it's the hook to integrate with a tracing
+// system without dependency, e.g. OpenTelemetry.
+//
+// The job's context is derived from the one passed to TimeFunc or ScheduleJob, thus it
+// carries the trace context at scheduling time. The Tracer can start a span that links
+// back to the span that scheduled the timer, e.g. "scheduled by X, fired after 3h".
+type Tracer interface {
+	// Start is called before the job runs with the job's context. It returns the
+	// context for the job which carries the new span, and the func to end the span
+	// with the job's result.
+	Start(ctx context.Context, info SpanInfo) (context.Context, func(err error))
+}
+
+// spanInfo returns the SpanInfo of the run of t scheduled at expiration.
+func (t *Timer) spanInfo(expiration int64, loc *time.Location) SpanInfo {
+	return SpanInfo{
+		Name:        t.Name(),
+		TimerID:     t.id,
+		ScheduledAt: msToTime(t.created).In(loc),
+		Expiration:  msToTime(expiration).In(loc),
+		Recurring:   t.sh != nil,
+	}
+}
@@ -0,0 +1,70 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type traceKey struct{}
+
+type testSpan struct {
+	info   SpanInfo
+	parent interface{}
+	err    error
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, info SpanInfo) (context.Context, func(err error)) {
+	span := &testSpan{info: info, parent: ctx.Value(traceKey{})}
+	return context.WithValue(ctx, traceKey{}, span), func(err error) {
+		tr.mu.Lock()
+		span.err = err
+		tr.spans = append(tr.spans, span)
+		tr.mu.Unlock()
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	tracer := new(testTracer)
+	tw := Default(WithTracer(tracer))
+	tw.Start()
+	defer tw.Stop()
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "scheduler-span")
+	spanC := make(chan interface{}, 1)
+	start := time.Now()
+	timer := tw.TimeFunc(ctx, start.Add(time.Millisecond*10), func(ctx context.Context) error {
+		spanC <- ctx.Value(traceKey{})
+		return errors.New("failed")
+	}, WithName("send-reminder"))
+
+	span := (<-spanC).(*testSpan)
+	require.Equal(t, "scheduler-span", span.parent)
+	require.Equal(t, "send-reminder", span.info.Name)
+	require.Equal(t, timer.ID(), span.info.TimerID)
+	require.False(t, span.info.Recurring)
+	require.Equal(t, timeToMs(start.Add(time.Millisecond*10)), timeToMs(span.info.Expiration))
+	require.WithinDuration(t, start, span.info.ScheduledAt, time.Millisecond*5)
+
+	require.Eventually(t, func() bool {
+		tracer.mu.Lock()
+		defer tracer.mu.Unlock()
+		return len(tracer.spans) == 1 && tracer.spans[0].err.Error() == "failed"
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestTimer_Name(t *testing.T) {
+	require.Equal(t, "timer", (&Timer{}).Name())
+	require.Equal(t, "k", (&Timer{key: "k"}).Name())
+	require.Equal(t, "send-email", (&Timer{key: "k", jobType: "send-email"}).Name())
+	require.Equal(t, "n", (&Timer{key: "k", jobType: "send-email", name: "n"}).Name())
+}
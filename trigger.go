@@ -0,0 +1,55 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrTimerClosed is returned when operates on a closed timer.
+	ErrTimerClosed = errors.New("timewheel: timer closed")
+	// ErrNoJob is returned when triggers a timer without job.
+	ErrNoJob = errors.New("timewheel: timer has no job")
+)
+
+// Trigger runs the job of t immediately by the Executor, without changing its
+// next expiration, e.g. for a manual backfill. The run follows the OverlapPolicy
+// of t and the MaxRunning of its tags, and is recorded in its Stats. Trigger waits until the job returns and
+// returns the job's error, or ErrOverlap if the run is skipped, or ctx.Err() if
+// ctx is done before it.
+//
+// The job is called with t's context instead of ctx.
+func (t *Timer) Trigger(ctx context.Context) error {
+	if t.tw == nil || t.jobFunc == nil {
+		return ErrNoJob
+	}
+	if atomic.LoadInt32(&t.closed) == 1 {
+		return ErrTimerClosed
+	}
+
+	tw := t.tw
+	errC := make(chan error, 1)
+	now := timeToMs(time.Now())
+	tw.executeRun(&quotaRun{t: t, at: now, triggered: true, fn: func() {
+		if atomic.LoadInt32(&t.closed) == 1 {
+			// The timer is closed while the run is deferred by quotas.
+			errC <- ErrTimerClosed
+			return
+		}
+		errC <- tw.exec(t, now, true)
+	}})
+
+	select {
+	case err := <-errC:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package timewheel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer_Trigger(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	runC := make(chan struct{}, 1)
+	timer := tw.ScheduleJob(context.Background(), Every(time.Hour), JobFunc(func(ctx context.Context) error {
+		runC <- struct{}{}
+		return errors.New("failed")
+	}))
+	expiration := timer.expiration
+
+	require.Equal(t, "failed", timer.Trigger(context.Background()).Error())
+	<-runC
+
+	// The next expiration is not changed.
+	require.Equal(t, expiration, timer.expiration)
+	require.Equal(t, 1, tw.Len())
+
+	stats := timer.Stats()
+	require.Equal(t, uint64(1), stats.Runs)
+	require.Equal(t, uint64(1), stats.Failures)
+	require.Equal(t, uint64(1), stats.Triggered)
+	require.Equal(t, "failed", stats.LastError.Error())
+	require.False(t, stats.LastStart.IsZero())
+
+	timer.Close()
+	require.Equal(t, ErrTimerClosed, timer.Trigger(context.Background()))
+
+	require.Equal(t, ErrNoJob, (&Timer{}).Trigger(context.Background()))
+}
+
+func TestTimer_Trigger_Context(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	doneC := make(chan struct{})
+	defer close(doneC)
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		<-doneC
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, timer.Trigger(ctx))
+}
+
+func TestTimer_Trigger_OverlapSkip(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	startC := make(chan struct{}, 1)
+	doneC := make(chan struct{})
+	timer := tw.TimeFunc(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		startC <- struct{}{}
+		<-doneC
+		return nil
+	}, WithOverlapPolicy(OverlapSkip))
+	defer timer.Close()
+
+	errC := make(chan error, 1)
+	go func() { errC <- timer.Trigger(context.Background()) }()
+	<-startC
+
+	// The job is still running.
+	require.Equal(t, ErrOverlap, timer.Trigger(context.Background()))
+	close(doneC)
+	require.NoError(t, <-errC)
+
+	stats := timer.Stats()
+	require.Equal(t, uint64(1), stats.Runs)
+	require.Equal(t, uint64(1), stats.Skipped)
+	require.Equal(t, uint64(1), stats.Triggered)
+	require.NoError(t, stats.LastError)
+}
+
+func TestTimer_Stats(t *testing.T) {
+	tw := Default()
+	tw.Start()
+	defer tw.Stop()
+
+	var count int32
+	doneC := make(chan struct{})
+	timer := tw.ScheduleJob(context.Background(), Every(time.Millisecond*5), JobFunc(func(ctx context.Context) error {
+		if atomic.AddInt32(&count, 1) == 1 {
+			close(doneC)
+		}
+		// Blocks until the timer is closed.
+		<-ctx.Done()
+		return nil
+	}), WithOverlapPolicy(OverlapSkip))
+
+	<-doneC
+	// Wait for the overlapped cycles.
+	time.Sleep(time.Millisecond * 50)
+	timer.Close()
+	time.Sleep(time.Millisecond * 10)
+
+	stats := timer.Stats()
+	require.Equal(t, int32(1), atomic.LoadInt32(&count))
+	require.Equal(t, uint64(1), stats.Runs)
+	require.Greater(t, stats.Skipped, uint64(0))
+	require.Equal(t, uint64(0), stats.Triggered)
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2020, Yu Wu <yu.771991@gmail.com> All rights reserved.
+//
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package timewheel
+
+import (
+	"sync"
+	"time"
+)
+
+// nilIndex is the index of none entry in Wheel.
+const nilIndex = -1
+
+// Wheel is a lightweight Hierarchical Timing Wheels that holds typed payloads instead
+// of jobs. It's designed for a large number of timers that just carry a value, e.g.
+// the session ids to expire.
+//
+// Each payload is added with a unique key, there is no goroutine, closure or context
+// allocated per timer. The expired payloads are delivered in batches to a handler
+// which is called in the Wheel's own goroutine, thus the handler must return quickly
+// and must not call Stop.
+type Wheel[T any] struct {
+	tick    int64 // The time span of each unit, in milliseconds.
+	size    int64 // The size of each level.
+	current int64 // The current time of the wheel, in milliseconds.
+
+	// The levels of wheel, the tick of level i is tick*size^i. Each slot holds the
+	// index of the first entry in its list.
+	levels [][]int32
+
+	// The entries and the free list of them, and the index of entries by key.
+	entries []wheelEntry[T]
+	free    int32
+	keys    map[string]int32
+
+	handler func(expired []T)
+
+	mu    sync.Mutex
+	once  sync.Once
+	exitC chan struct{}
+	wg    sync.WaitGroup
+}
+
+// wheelEntry is a timer in Wheel, it's linked into the list of its slot by index.
+type wheelEntry[T any] struct {
+	key        string
+	payload    T
+	expiration int64 // in milliseconds.
+	level      int32
+	slot       int32
+	prev       int32
+	next       int32
+}
+
+// NewWheel creates a Wheel with the given tick and size of each level, the expired
+// payloads are passed to handler in batches. The value of tick must >= 1ms, the size
+// must >= 2, otherwise a level never covers more time than the one below it.
+func NewWheel[T any](tick time.Duration, size int64, handler func(expired []T)) *Wheel[T] {
+	if tick < time.Millisecond {
+		panic("timewheel: tick must be greater than or equal to 1ms")
+	}
+	if size < 2 {
+		panic("timewheel: size must be greater than 1")
+	}
+	if handler == nil {
+		panic("timewheel: handler must not be nil")
+	}
+
+	tickMs := durationToMs(tick)
+	return &Wheel[T]{
+		tick:    tickMs,
+		size:    size,
+		current: truncate(timeToMs(time.Now()), tickMs),
+		levels:  nil,
+		entries: nil,
+		free:    nilIndex,
+		keys:    make(map[string]int32),
+		handler: handler,
+		exitC:   make(chan struct{}),
+	}
+}
+
+// NewWheelChan creates a Wheel like NewWheel, but delivers the expired payloads in
+// batches on the returned channel with the given buffer size. The Wheel is blocked
+// if the channel is full, and the channel is closed after the Wheel is stopped.
+func NewWheelChan[T any](tick time.Duration, size int64, buffer int) (*Wheel[T], <-chan []T) {
+	c := make(chan []T, buffer)
+	var w *Wheel[T]
+	w = NewWheel(tick, size, func(expired []T) {
+		select {
+		case c <- expired:
+		case <-w.exitC:
+		}
+	})
+	go func() {
+		<-w.exitC
+		w.wg.Wait()
+		close(c)
+	}()
+	return w, c
+}
+
+// Start starts the Wheel in a goroutine.
+func (w *Wheel[T]) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(time.Duration(w.tick) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.exitC:
+				return
+			case now := <-ticker.C:
+				if expired := w.advance(timeToMs(now)); len(expired) != 0 {
+					w.handler(expired)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the Wheel and waits for its goroutine to exit. The pending payloads
+// are kept and never delivered.
+func (w *Wheel[T]) Stop() {
+	w.once.Do(func() { close(w.exitC) })
+	w.wg.Wait()
+}
+
+// Add adds the payload with key that expires at the given time, it replaces the
+// pending one with the same key. The payload that has been expired is delivered
+// at the next tick.
+func (w *Wheel[T]) Add(key string, payload T, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if i, ok := w.keys[key]; ok {
+		w.unlink(i)
+		w.release(i)
+	}
+
+	i := w.alloc()
+	e := &w.entries[i]
+	e.key = key
+	e.payload = payload
+	e.expiration = timeToMs(at)
+	w.keys[key] = i
+	w.insert(i)
+}
+
+// Remove removes the pending payload of key, returns false if not found.
+func (w *Wheel[T]) Remove(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i, ok := w.keys[key]
+	if !ok {
+		return false
+	}
+	w.unlink(i)
+	w.release(i)
+	return true
+}
+
+// Get returns the pending payload of key and its expiration time.
+func (w *Wheel[T]) Get(key string) (payload T, at time.Time, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i, ok := w.keys[key]
+	if !ok {
+		return payload, at, false
+	}
+	e := &w.entries[i]
+	return e.payload, msToTime(e.expiration), true
+}
+
+// Len returns the number of pending payloads.
+func (w *Wheel[T]) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.keys)
+}
+
+// advance pushes the clock forward to now tick by tick, and returns the payloads
+// that expired.
+func (w *Wheel[T]) advance(now int64) []T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var expired []T
+	for w.current+w.tick <= now {
+		if len(w.keys) == 0 {
+			// Nothing to do, jump to now directly.
+			w.current = truncate(now, w.tick)
+			break
+		}
+		w.current += w.tick
+
+		// Move the timers from the higher levels whose slot starts now.
+		tick := w.tick
+		for level := 1; level < len(w.levels); level++ {
+			tick *= w.size
+			if w.current%tick != 0 {
+				break
+			}
+			expired = w.flush(level, int32((w.current/tick)%w.size), expired)
+		}
+		expired = w.flush(0, int32((w.current/w.tick)%w.size), expired)
+	}
+	return expired
+}
+
+// flush removes all timers in the slot of level, and reinserts them. The expired
+// ones are appended to expired.
+func (w *Wheel[T]) flush(level int, slot int32, expired []T) []T {
+	i := w.levels[level][slot]
+	w.levels[level][slot] = nilIndex
+
+	for i != nilIndex {
+		e := &w.entries[i]
+		next := e.next
+		if e.expiration < w.current+w.tick {
+			expired = append(expired, e.payload)
+			w.release(i)
+		} else {
+			w.insert(i)
+		}
+		i = next
+	}
+	return expired
+}
+
+// insert links the entry i into the slot it belongs to. The expired entry is put
+// into the slot of next tick.
+func (w *Wheel[T]) insert(i int32) {
+	e := &w.entries[i]
+
+	expiration := e.expiration
+	if expiration < w.current+w.tick {
+		expiration = w.current + w.tick
+	}
+
+	level, tick := 0, w.tick
+	for expiration >= truncate(w.current, tick)+tick*w.size {
+		level++
+		tick *= w.size
+	}
+	for len(w.levels) <= level {
+		slots := make([]int32, w.size)
+		for j := range slots {
+			slots[j] = nilIndex
+		}
+		w.levels = append(w.levels, slots)
+	}
+
+	slot := int32((expiration / tick) % w.size)
+	head := w.levels[level][slot]
+	e.level = int32(level)
+	e.slot = slot
+	e.prev = nilIndex
+	e.next = head
+	if head != nilIndex {
+		w.entries[head].prev = i
+	}
+	w.levels[level][slot] = i
+}
+
+// unlink removes the entry i from the list of its slot.
+func (w *Wheel[T]) unlink(i int32) {
+	e := &w.entries[i]
+	if e.prev != nilIndex {
+		w.entries[e.prev].next = e.next
+	} else {
+		w.levels[e.level][e.slot] = e.next
+	}
+	if e.next != nilIndex {
+		w.entries[e.next].prev = e.prev
+	}
+}
+
+// alloc returns the index of a free entry.
+func (w *Wheel[T]) alloc() int32 {
+	if w.free == nilIndex {
+		w.entries = append(w.entries, wheelEntry[T]{})
+		return int32(len(w.entries) - 1)
+	}
+	i := w.free
+	w.free = w.entries[i].next
+	return i
+}
+
+// release removes the key of entry i and puts it into the free list.
+func (w *Wheel[T]) release(i int32) {
+	e := &w.entries[i]
+	delete(w.keys, e.key)
+	*e = wheelEntry[T]{next: w.free}
+	w.free = i
+}
@@ -0,0 +1,121 @@
+package timewheel
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWheel_advance(t *testing.T) {
+	var expired []int
+	w := NewWheel(time.Millisecond, 4, func(v []int) { expired = append(expired, v...) })
+
+	start := w.current
+	offsets := []int64{3, 1, 5, 17, 64, 65, 300, 0, -10}
+	for i, d := range offsets {
+		w.Add(strconv.Itoa(i), i, msToTime(start+d))
+	}
+	require.Equal(t, len(offsets), w.Len())
+	require.Greater(t, len(w.levels), 3)
+
+	// Replaces the pending one.
+	w.Add("3", 3, msToTime(start+20))
+	require.Equal(t, len(offsets), w.Len())
+	require.True(t, w.Remove("5"))
+	require.False(t, w.Remove("5"))
+	payload, at, ok := w.Get("6")
+	require.True(t, ok)
+	require.Equal(t, 6, payload)
+	require.Equal(t, start+300, timeToMs(at))
+
+	steps := []struct {
+		now    int64
+		expect []int
+	}{
+		{now: 1, expect: []int{1, 7, 8}},
+		{now: 3, expect: []int{0}},
+		{now: 19, expect: []int{2}},
+		{now: 20, expect: []int{3}},
+		{now: 64, expect: []int{4}},
+		{now: 299, expect: nil},
+		{now: 1000, expect: []int{6}},
+	}
+	for _, step := range steps {
+		got := w.advance(start + step.now)
+		sort.Ints(got)
+		require.Equal(t, step.expect, got, "now: %d", step.now)
+	}
+	require.Equal(t, 0, w.Len())
+
+	// The entries are reused.
+	n := len(w.entries)
+	w.Add("a", 1, msToTime(w.current+10))
+	require.Equal(t, n, len(w.entries))
+	require.Equal(t, []int{1}, w.advance(w.current+10))
+}
+
+func TestWheel_Start(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[int]time.Time)
+	w := NewWheel(time.Millisecond, 8, func(expired []int) {
+		now := time.Now()
+		mu.Lock()
+		for _, v := range expired {
+			got[v] = now
+		}
+		mu.Unlock()
+	})
+	w.Start()
+	defer w.Stop()
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		w.Add(strconv.Itoa(i), i, start.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 100
+	}, time.Second, time.Millisecond*10)
+	for i, at := range got {
+		require.False(t, at.Before(start.Add(time.Duration(i-1)*time.Millisecond)), "payload %d", i)
+	}
+	require.Equal(t, 0, w.Len())
+}
+
+func TestNewWheel_Invalid(t *testing.T) {
+	handler := func(expired []string) {}
+	require.Panics(t, func() { NewWheel(0, 8, handler) })
+	require.Panics(t, func() { NewWheel(-time.Millisecond, 8, handler) })
+	require.Panics(t, func() { NewWheel(time.Microsecond, 8, handler) })
+	require.Panics(t, func() { NewWheel(time.Millisecond, 0, handler) })
+	require.Panics(t, func() { NewWheel(time.Millisecond, 1, handler) })
+	require.Panics(t, func() { NewWheel[string](time.Millisecond, 8, nil) })
+	require.NotPanics(t, func() { NewWheel(time.Millisecond, 2, handler) })
+}
+
+func TestNewWheelChan(t *testing.T) {
+	w, c := NewWheelChan[string](time.Millisecond, 8, 1)
+	w.Start()
+
+	w.Add("a", "a", time.Now().Add(time.Millisecond*20))
+	w.Add("b", "b", time.Now().Add(time.Millisecond*20))
+	w.Add("c", "c", time.Now().Add(time.Hour))
+
+	var got []string
+	for len(got) < 2 {
+		got = append(got, <-c...)
+	}
+	sort.Strings(got)
+	require.Equal(t, []string{"a", "b"}, got)
+
+	w.Stop()
+	_, ok := <-c
+	require.False(t, ok)
+	require.Equal(t, 1, w.Len())
+}